@@ -0,0 +1,727 @@
+// Package apitest provides an in-process fake urlscan.io API server for hermetic testing.
+//
+// The server keeps its state in memory and emulates the scan, result, search, DOM,
+// screenshot, datadump, livescan and /user/* CRUD endpoints closely enough for
+// api.Client and the CLI to work against it:
+//
+//	s := apitest.NewServer(apitest.WithNotReadyPolls(1))
+//	defer s.Close()
+//
+//	c := api.NewClient(apitest.APIKey)
+//	c.SetBaseURL(s.BaseURL())
+package apitest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+// APIKey is the API key accepted by the server by default.
+const APIKey = "apitest-api-key"
+
+// collections maps the /user/* collection names to the JSON keys of the wrapped objects.
+var collections = map[string]string{
+	"channels":      "channel",
+	"incidents":     "incident",
+	"searches":      "search",
+	"subscriptions": "subscription",
+}
+
+// Scan is a scan stored in the server.
+type Scan struct {
+	UUID       string
+	URL        string
+	Visibility string
+	Tags       []string
+	Time       time.Time
+	// polls is the number of result requests answered with 404 before the result is ready.
+	polls int
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	apiKey         string
+	latency        time.Duration
+	notReadyPolls  int
+	blockedDomains []string
+	rateLimited    int
+	resetAfter     int
+	scans          []*Scan
+	dataDumps      map[string][]byte
+	liveScans      map[string]*Scan
+	objects        map[string]map[string]json.RawMessage
+	requests       []string
+	quotas         map[string]int
+	used           map[string]int
+}
+
+type Option func(*Server)
+
+// WithAPIKey sets the API key accepted by the server. An empty key disables authentication.
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithLatency delays every response by d.
+func WithLatency(d time.Duration) Option {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// WithNotReadyPolls makes result requests of a new scan return 404 n times before the result is ready.
+func WithNotReadyPolls(n int) Option {
+	return func(s *Server) {
+		s.notReadyPolls = n
+	}
+}
+
+// WithBlockedDomains makes scan requests for the domains (and their subdomains) fail with 400.
+func WithBlockedDomains(domains ...string) Option {
+	return func(s *Server) {
+		s.blockedDomains = domains
+	}
+}
+
+// WithQuota makes the quotas endpoint report a quota of limit requests for the action
+// (e.g. "public", "private", "search") in each of the minute, hour and day windows.
+// Every request of the action since the server started counts as used.
+func WithQuota(action string, limit int) Option {
+	return func(s *Server) {
+		s.quotas[action] = limit
+	}
+}
+
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		Server:         nil,
+		mu:             sync.Mutex{},
+		apiKey:         APIKey,
+		latency:        0,
+		notReadyPolls:  0,
+		blockedDomains: nil,
+		rateLimited:    0,
+		resetAfter:     0,
+		scans:          nil,
+		dataDumps:      make(map[string][]byte),
+		liveScans:      make(map[string]*Scan),
+		objects:        make(map[string]map[string]json.RawMessage),
+		requests:       nil,
+		quotas:         make(map[string]int),
+		used:           make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for name := range collections {
+		s.objects[name] = make(map[string]json.RawMessage)
+	}
+
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// BaseURL returns the base URL of the server to be used with api.Client.SetBaseURL.
+func (s *Server) BaseURL() *url.URL {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// RateLimitNext makes the next n requests fail with 429 and X-Rate-Limit-Reset-After: resetAfter.
+func (s *Server) RateLimitNext(n int, resetAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = n
+	s.resetAfter = resetAfter
+}
+
+// AddScan stores a finished scan of u and returns it.
+func (s *Server) AddScan(u string) *Scan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addScan(u, "public", nil, 0)
+}
+
+// AddDataDump stores a datadump file at path (e.g. "hours/api/20260101/20260101-01.gz").
+func (s *Server) AddDataDump(path string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dataDumps[strings.Trim(path, "/")] = content
+}
+
+// Scans returns the stored scans.
+func (s *Server) Scans() []*Scan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.scans)
+}
+
+// Requests returns the received requests formatted as "METHOD /path".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func (s *Server) addScan(u, visibility string, tags []string, polls int) *Scan {
+	scan := &Scan{
+		UUID:       newUUID(),
+		URL:        u,
+		Visibility: visibility,
+		Tags:       tags,
+		Time:       time.Now().UTC(),
+		polls:      polls,
+	}
+	s.scans = append(s.scans, scan)
+	return scan
+}
+
+func (s *Server) findScan(uuid string) *Scan {
+	for _, scan := range s.scans {
+		if scan.UUID == uuid {
+			return scan
+		}
+	}
+	return s.liveScans[uuid]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message, description string) {
+	writeJSON(w, status, map[string]any{
+		"status":      status,
+		"message":     message,
+		"description": description,
+	})
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/v1/scan/", s.handleScan)
+	mux.HandleFunc("GET /api/v1/result/{uuid}/", s.handleResult)
+	mux.HandleFunc("GET /api/v1/search/", s.handleSearch)
+	mux.HandleFunc("GET /api/v1/search", s.handleSearch)
+	mux.HandleFunc("GET /api/v1/quotas", s.handleQuotas)
+	mux.HandleFunc("GET /dom/{uuid}/", s.handleDOM)
+	mux.HandleFunc("GET /screenshots/{file}", s.handleScreenshot)
+	mux.HandleFunc("GET /api/v1/datadump/list/{path...}", s.handleDataDumpList)
+	mux.HandleFunc("GET /api/v1/datadump/link/{path...}", s.handleDataDumpLink)
+	mux.HandleFunc("GET /api/v1/livescan/scanners", s.handleLiveScanScanners)
+	mux.HandleFunc("POST /api/v1/livescan/{scanner}/scan/", s.handleLiveScan)
+	mux.HandleFunc("POST /api/v1/livescan/{scanner}/task/", s.handleLiveScan)
+	mux.HandleFunc("GET /api/v1/livescan/{scanner}/result/{uuid}", s.handleResult)
+	mux.HandleFunc("GET /api/v1/livescan/{scanner}/dom/{uuid}", s.handleDOM)
+	mux.HandleFunc("GET /api/v1/livescan/{scanner}/screenshot/{uuid}/", s.handleScreenshot)
+	mux.HandleFunc("PUT /api/v1/livescan/{scanner}/{uuid}/", s.handleLiveScanStore)
+	mux.HandleFunc("DELETE /api/v1/livescan/{scanner}/{uuid}/", s.handleLiveScanPurge)
+	mux.HandleFunc("/api/v1/user/{collection}/{rest...}", s.handleCollection)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+		latency := s.latency
+		s.mu.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if s.apiKey != "" && r.Header.Get("API-Key") != s.apiKey {
+			writeError(w, http.StatusUnauthorized, "Unauthorized", "Invalid API key")
+			return
+		}
+
+		s.mu.Lock()
+		rateLimited := s.rateLimited > 0
+		if rateLimited {
+			s.rateLimited--
+		}
+		resetAfter := s.resetAfter
+		s.mu.Unlock()
+		if rateLimited {
+			w.Header().Set("X-Rate-Limit-Reset-After", strconv.Itoa(resetAfter))
+			writeError(w, http.StatusTooManyRequests, "Rate limit exceeded", "")
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) isBlocked(host string) bool {
+	for _, domain := range s.blockedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		URL        string   `json:"url"`
+		Visibility string   `json:"visibility"`
+		Tags       []string `json:"tags"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.URL == "" {
+		writeError(w, http.StatusBadRequest, "Missing URL properties", "The URL supplied was not OK, please specify it including the protocol, host and path (e.g. http://example.com/bar)")
+		return
+	}
+
+	u, err := url.Parse(body.URL)
+	if err != nil || u.Hostname() == "" {
+		writeError(w, http.StatusBadRequest, "Missing URL properties", "The URL supplied was not OK, please specify it including the protocol, host and path (e.g. http://example.com/bar)")
+		return
+	}
+	host := u.Hostname()
+	// reserved TLD which never resolves (RFC 2606)
+	if strings.HasSuffix(host, ".invalid") {
+		writeError(w, http.StatusBadRequest, "DNS Error - Could not resolve domain", fmt.Sprintf("The domain %s could not be resolved to a valid IPv4/IPv6 address. We won't try to load it in the browser.", host))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isBlocked(host) {
+		writeError(w, http.StatusBadRequest, "Scan prevented - Blocklisted domain", fmt.Sprintf("The domain %s is blocked from scanning", host))
+		return
+	}
+
+	visibility := body.Visibility
+	if visibility == "" {
+		visibility = "public"
+	}
+	scan := s.addScan(body.URL, visibility, body.Tags, s.notReadyPolls)
+	s.used[visibility]++
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message":    "Submission successful",
+		"uuid":       scan.UUID,
+		"result":     fmt.Sprintf("%s/result/%s/", s.URL, scan.UUID),
+		"api":        fmt.Sprintf("%s/api/v1/result/%s/", s.URL, scan.UUID),
+		"visibility": scan.Visibility,
+		"url":        scan.URL,
+	})
+}
+
+func (scan *Scan) domain() string {
+	u, err := url.Parse(scan.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func (scan *Scan) task() map[string]any {
+	return map[string]any{
+		"uuid":       scan.UUID,
+		"url":        scan.URL,
+		"visibility": scan.Visibility,
+		"tags":       scan.Tags,
+		"time":       scan.Time.Format(time.RFC3339Nano),
+		"method":     "api",
+	}
+}
+
+func (scan *Scan) page() map[string]any {
+	return map[string]any{
+		"url":    scan.URL,
+		"domain": scan.domain(),
+		"status": "200",
+	}
+}
+
+func (scan *Scan) result() map[string]any {
+	return map[string]any{
+		"data":  map[string]any{"requests": []any{}, "cookies": []any{}, "console": []any{}, "links": []any{}, "timing": map[string]any{}, "globals": []any{}},
+		"stats": map[string]any{"uniqIPs": 1, "uniqCountries": 1, "dataLength": 0, "encodedDataLength": 0, "requests": 1},
+		"meta":  map[string]any{},
+		"task":  scan.task(),
+		"page":  scan.page(),
+		"lists": map[string]any{"ips": []string{}, "countries": []string{}, "domains": []string{scan.domain()}, "urls": []string{scan.URL}},
+		"verdicts": map[string]any{
+			"overall": map[string]any{"score": 0, "malicious": false, "hasVerdicts": false},
+		},
+	}
+}
+
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	scan := s.findScan(r.PathValue("uuid"))
+	ready := scan != nil && scan.polls <= 0
+	if scan != nil && !ready {
+		scan.polls--
+	}
+	s.mu.Unlock()
+
+	if !ready {
+		writeError(w, http.StatusNotFound, "Scan is not finished yet", "We could not find this page. Maybe it's not finished yet, or it was deleted.")
+		return
+	}
+	writeJSON(w, http.StatusOK, scan.result())
+}
+
+func (s *Server) readyScan(uuid string) *Scan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scan := s.findScan(uuid)
+	if scan == nil || scan.polls > 0 {
+		return nil
+	}
+	return scan
+}
+
+func (s *Server) handleDOM(w http.ResponseWriter, r *http.Request) {
+	scan := s.readyScan(r.PathValue("uuid"))
+	if scan == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><head><title>%s</title></head><body></body></html>", scan.URL) //nolint:errcheck
+}
+
+// png is the 8-byte PNG signature, enough for file type detection.
+var png = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if uuid == "" {
+		uuid = strings.TrimSuffix(r.PathValue("file"), ".png")
+	}
+	scan := s.readyScan(uuid)
+	if scan == nil {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(png)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	size, err := strconv.Atoi(r.URL.Query().Get("size"))
+	if err != nil || size <= 0 {
+		size = 100
+	}
+	searchAfter := r.URL.Query().Get("search_after")
+
+	s.mu.Lock()
+	s.used["search"]++
+	s.mu.Unlock()
+
+	// supports field:value terms (matched against the domain and URL) and plain text
+	var terms []string
+	for term := range strings.FieldsSeq(q) {
+		if term == "AND" {
+			continue
+		}
+		_, value, ok := strings.Cut(term, ":")
+		if ok {
+			term = value
+		}
+		terms = append(terms, strings.Trim(term, `"`))
+	}
+
+	s.mu.Lock()
+	matched := make([]*Scan, 0, len(s.scans))
+	for _, scan := range s.scans {
+		if scan.polls > 0 {
+			continue
+		}
+		ok := true
+		for _, term := range terms {
+			if !strings.Contains(scan.URL, term) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, scan)
+		}
+	}
+	s.mu.Unlock()
+
+	// newest first, the same order as urlscan.io
+	slices.SortStableFunc(matched, func(a, b *Scan) int {
+		if c := b.Time.Compare(a.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(b.UUID, a.UUID)
+	})
+
+	start := 0
+	if searchAfter != "" {
+		for i, scan := range matched {
+			if fmt.Sprintf("%d,%s", scan.Time.UnixMilli(), scan.UUID) == searchAfter {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := min(start+size, len(matched))
+
+	results := make([]map[string]any, 0, end-start)
+	for _, scan := range matched[start:end] {
+		results = append(results, map[string]any{
+			"_id":  scan.UUID,
+			"task": scan.task(),
+			"page": scan.page(),
+			"sort": []any{scan.Time.UnixMilli(), scan.UUID},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results":  results,
+		"total":    len(matched),
+		"took":     1,
+		"has_more": end < len(matched),
+	})
+}
+
+func (s *Server) handleQuotas(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	limits := make(map[string]api.QuotaLimits, len(s.quotas))
+	for action, limit := range s.quotas {
+		used := s.used[action]
+		window := &api.QuotaWindow{
+			Limit:     limit,
+			Used:      used,
+			Remaining: max(limit-used, 0),
+			Percent:   float64(used) / float64(max(limit, 1)) * 100,
+		}
+		limits[action] = api.QuotaLimits{Minute: window, Hour: window, Day: window}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"scope":  "user",
+		"limits": limits,
+	})
+}
+
+func (s *Server) handleDataDumpList(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.Trim(r.PathValue("path"), "/")
+
+	s.mu.Lock()
+	files := make([]api.DataDumpFile, 0)
+	for path, content := range s.dataDumps {
+		if strings.HasPrefix(path, prefix) {
+			files = append(files, api.DataDumpFile{
+				Path:      path,
+				Size:      int64(len(content)),
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	slices.SortFunc(files, func(a, b api.DataDumpFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"files": files})
+}
+
+func (s *Server) handleDataDumpLink(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.PathValue("path"), "/")
+
+	s.mu.Lock()
+	content, ok := s.dataDumps[path]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	// ServeContent handles Range requests for resuming downloads
+	w.Header().Set("Content-Type", "application/gzip")
+	http.ServeContent(w, r, path, time.Time{}, bytes.NewReader(content))
+}
+
+func (s *Server) handleLiveScanScanners(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"scanners": []map[string]any{
+			{"id": "de01", "country": "de", "name": "Germany"},
+			{"id": "us01", "country": "us", "name": "United States"},
+		},
+	})
+}
+
+func (s *Server) handleLiveScan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Task struct {
+			URL        string `json:"url"`
+			Visibility string `json:"visibility"`
+		} `json:"task"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Task.URL == "" {
+		writeError(w, http.StatusBadRequest, "Missing URL properties", "")
+		return
+	}
+
+	s.mu.Lock()
+	scan := &Scan{
+		UUID:       newUUID(),
+		URL:        body.Task.URL,
+		Visibility: body.Task.Visibility,
+		Tags:       nil,
+		Time:       time.Now().UTC(),
+		polls:      0,
+	}
+	s.liveScans[scan.UUID] = scan
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"uuid": scan.UUID})
+}
+
+func (s *Server) handleLiveScanStore(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scan, ok := s.liveScans[r.PathValue("uuid")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	// storing makes the live scan searchable as a regular scan
+	delete(s.liveScans, scan.UUID)
+	s.scans = append(s.scans, scan)
+	writeJSON(w, http.StatusOK, map[string]any{"uuid": scan.UUID})
+}
+
+func (s *Server) handleLiveScanPurge(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.liveScans[r.PathValue("uuid")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	delete(s.liveScans, r.PathValue("uuid"))
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// handleCollection emulates the CRUD endpoints of /user/{channels,incidents,searches,subscriptions}.
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("collection")
+	key, ok := collections[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+	id := strings.Trim(r.PathValue("rest"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects := s.objects[name]
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			list := make([]json.RawMessage, 0, len(objects))
+			for _, v := range objects {
+				list = append(list, v)
+			}
+			writeJSON(w, http.StatusOK, map[string]any{name: list})
+		case http.MethodPost:
+			obj, err := decodeObject(r, key)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+				return
+			}
+			s.putObject(w, name, key, newUUID(), obj)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed", "")
+		}
+		return
+	}
+
+	current, ok := objects[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]json.RawMessage{key: current})
+	case http.MethodPut:
+		obj, err := decodeObject(r, key)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		s.putObject(w, name, key, id, obj)
+	case http.MethodDelete:
+		delete(objects, id)
+		writeJSON(w, http.StatusOK, map[string]any{})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed", "")
+	}
+}
+
+// decodeObject decodes a request body wrapped with key (e.g. {"channel": {...}}).
+func decodeObject(r *http.Request, key string) (map[string]any, error) {
+	var body map[string]map[string]any
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := body[key]
+	if !ok || obj == nil {
+		return nil, fmt.Errorf("missing %q property", key)
+	}
+	return obj, nil
+}
+
+func (s *Server) putObject(w http.ResponseWriter, name, key, id string, obj map[string]any) {
+	obj["_id"] = id
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		return
+	}
+	s.objects[name][id] = raw
+	writeJSON(w, http.StatusOK, map[string]json.RawMessage{key: raw})
+}
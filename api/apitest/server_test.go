@@ -0,0 +1,192 @@
+package apitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+func newTestClient(s *Server) *api.Client {
+	c := api.NewClient(APIKey)
+	c.SetBaseURL(s.BaseURL())
+	return c
+}
+
+func TestScanAndWait(t *testing.T) {
+	s := NewServer(WithNotReadyPolls(1))
+	defer s.Close()
+
+	c := newTestClient(s)
+	scan, err := c.ScanContext(t.Context(), "https://example.com/")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, scan.UUID)
+
+	_, err = c.GetResultContext(t.Context(), scan.UUID)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+
+	result, err := c.GetResultTypedContext(t.Context(), scan.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, scan.UUID, result.Task.UUID)
+	assert.Equal(t, "example.com", result.Page.Domain)
+}
+
+func TestScanRefused(t *testing.T) {
+	s := NewServer(WithBlockedDomains("example.net"))
+	defer s.Close()
+
+	c := newTestClient(s)
+	_, err := c.Scan("https://www.example.net/")
+	assert.ErrorIs(t, err, api.ErrScanBlocked)
+
+	_, err = c.Scan("https://nx.invalid/")
+	assert.ErrorIs(t, err, api.ErrDNSFailure)
+}
+
+func TestUnauthorized(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	c := newTestClient(s).SetAPIKey("wrong")
+	_, err := c.Scan("https://example.com/")
+	assert.ErrorIs(t, err, api.ErrUnauthorized)
+}
+
+func TestRateLimitNext(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddScan("https://example.com/")
+
+	c := newTestClient(s)
+	c.SetRetryPolicy(&api.RetryPolicy{
+		MaxAttempts:          1,
+		BaseDelay:            0,
+		MaxDelay:             0,
+		Jitter:               0,
+		RetryableStatusCodes: nil,
+		IsRetryableError:     nil,
+		RetryNonIdempotent:   false,
+	})
+
+	s.RateLimitNext(1, 0)
+	_, err := c.NewRequest().Get(api.PrefixedPath("/search/"))
+	assert.ErrorIs(t, err, api.ErrRateLimited)
+
+	// retried by the default policy
+	c.SetRetryPolicy(api.DefaultRetryPolicy())
+	s.RateLimitNext(1, 0)
+	_, err = c.NewRequest().Get(api.PrefixedPath("/search/"))
+	assert.NoError(t, err)
+}
+
+func TestSearch(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	for range 5 {
+		s.AddScan("https://example.com/")
+	}
+	s.AddScan("https://example.org/")
+
+	c := newTestClient(s)
+	it, err := c.Search("page.domain:example.com", api.IteratorSize(2), api.IteratorAll(true))
+	assert.NoError(t, err)
+
+	var uuids []string
+	for result, err := range it.Iterate() {
+		assert.NoError(t, err)
+		uuids = append(uuids, string(result.Raw))
+	}
+	assert.Len(t, uuids, 5)
+	assert.Equal(t, 5, it.Total)
+}
+
+func TestDownload(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	scan := s.AddScan("https://example.com/")
+	s.AddDataDump("hours/api/20260101/20260101-01.gz", []byte("0123456789"))
+
+	c := newTestClient(s)
+	dir := t.TempDir()
+
+	_, err := c.DownloadContext(t.Context(), "/screenshots/"+scan.UUID+".png", filepath.Join(dir, "screenshot.png"))
+	assert.NoError(t, err)
+
+	list, err := c.GetDataDumpListContext(t.Context(), "hours/api/20260101/")
+	assert.NoError(t, err)
+	assert.Len(t, list.Files, 1)
+
+	// resume from a partial file
+	output := filepath.Join(dir, "dump.gz")
+	assert.NoError(t, os.WriteFile(api.PartialPath(output), []byte("01234"), 0o600))
+	n, err := c.DownloadContext(t.Context(), api.PrefixedPath("/datadump/link/hours/api/20260101/20260101-01.gz"), output)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), n)
+	content, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(content))
+}
+
+func TestCollections(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	c := newTestClient(s)
+	resp, err := c.CreateSavedSearch(api.WithSavedSearchName("foo"), api.WithSavedSearchQuery("page.domain:example.com"))
+	assert.NoError(t, err)
+
+	var created struct {
+		Search struct {
+			ID   string `json:"_id"`
+			Name string `json:"name"`
+		} `json:"search"`
+	}
+	assert.NoError(t, resp.Unmarshal(&created))
+	assert.NotEmpty(t, created.Search.ID)
+
+	_, err = c.UpdateSavedSearch(created.Search.ID, api.WithSavedSearchName("bar"))
+	assert.NoError(t, err)
+
+	resp, err = c.NewRequest().Get(api.PrefixedPath("/user/searches/" + created.Search.ID + "/"))
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Unmarshal(&created))
+	assert.Equal(t, "bar", created.Search.Name)
+
+	_, err = c.NewRequest().Delete(api.PrefixedPath("/user/searches/" + created.Search.ID + "/"))
+	assert.NoError(t, err)
+	_, err = c.NewRequest().Get(api.PrefixedPath("/user/searches/" + created.Search.ID + "/"))
+	assert.ErrorIs(t, err, api.ErrNotFound)
+}
+
+func TestLatency(t *testing.T) {
+	s := NewServer(WithLatency(50 * time.Millisecond))
+	defer s.Close()
+
+	c := newTestClient(s)
+	start := time.Now()
+	_, err := c.NewRequest().Get(api.PrefixedPath("/quotas"))
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestQuotas(t *testing.T) {
+	s := NewServer(WithQuota("public", 2))
+	defer s.Close()
+
+	c := newTestClient(s)
+	_, err := c.ScanContext(t.Context(), "https://example.com/")
+	assert.NoError(t, err)
+
+	q, err := c.GetQuotasContext(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, q.Limits["public"].Day.Used)
+	assert.Equal(t, 1, q.Limits["public"].Minute.Remaining)
+
+	plan := q.Plan("public", 3)
+	assert.True(t, plan.Exceeded())
+	assert.Equal(t, 1, plan.Allowed)
+}
@@ -5,17 +5,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"sync"
 	"time"
 
 	"github.com/samber/mo"
+	"go.opentelemetry.io/otel/attribute"
 
 	"golang.org/x/sync/errgroup"
 )
 
+// ErrTaskNotStarted is the error of a task which was not started because the batch was stopped.
+var ErrTaskNotStarted = errors.New("task not started")
+
 type BatchOptions struct {
+	// Context stops the batch when it's done. The running tasks are canceled and the
+	// remaining ones are not started.
+	Context        context.Context
 	MaxConcurrency int
-	Timeout        int
+	// Timeout is the timeout of the whole batch in seconds.
+	Timeout int
+	// TaskTimeout is the timeout of each attempt of a task in seconds.
+	TaskTimeout int
+	// RetryPolicy is the retry policy of a failed task. A task is not retried if it's nil.
+	RetryPolicy *RetryPolicy
+	// OnStart is called with the index of a task when the task starts.
+	OnStart func(index int)
+	// OnDone is called with the index of a task and its error (nil on success) when the task
+	// is done, after the retries.
+	OnDone func(index int, err error)
 }
 
 type BatchOption func(*BatchOptions)
@@ -32,6 +50,43 @@ func WithBatchTimeout(timeout int) BatchOption {
 	}
 }
 
+func WithBatchContext(ctx context.Context) BatchOption {
+	return func(opts *BatchOptions) {
+		opts.Context = ctx
+	}
+}
+
+// WithBatchTaskTimeout sets the timeout of each attempt of a task in seconds, 0 means no timeout.
+func WithBatchTaskTimeout(timeout int) BatchOption {
+	return func(opts *BatchOptions) {
+		opts.TaskTimeout = timeout
+	}
+}
+
+// WithBatchRetryPolicy retries a failed task with the policy. A task is retried if its error
+// is a JSONError with one of the RetryableStatusCodes (except for an exceeded quota), the
+// policy's IsRetryableError accepts the error, or the attempt hit the task timeout.
+// Note that a retry runs the whole task again (e.g. a scan is submitted again).
+func WithBatchRetryPolicy(policy *RetryPolicy) BatchOption {
+	return func(opts *BatchOptions) {
+		opts.RetryPolicy = policy
+	}
+}
+
+// WithBatchOnStart sets the callback called when a task starts. It's called concurrently.
+func WithBatchOnStart(fn func(index int)) BatchOption {
+	return func(opts *BatchOptions) {
+		opts.OnStart = fn
+	}
+}
+
+// WithBatchOnDone sets the callback called when a task is done. It's called concurrently.
+func WithBatchOnDone(fn func(index int, err error)) BatchOption {
+	return func(opts *BatchOptions) {
+		opts.OnDone = fn
+	}
+}
+
 func newBatchOptions(opts ...BatchOption) *BatchOptions {
 	var o BatchOptions
 	for _, fn := range opts {
@@ -42,48 +97,159 @@ func newBatchOptions(opts ...BatchOption) *BatchOptions {
 
 type BatchTask[T any] func(c *Client, ctx context.Context) mo.Result[T]
 
+// Batch runs the tasks concurrently and returns their results in the order of the tasks.
+// If the batch is stopped by its context, the result of a task which was not started is
+// ErrTaskNotStarted.
 func Batch[T any](c *Client, tasks []BatchTask[T], opts ...BatchOption) ([]mo.Result[T], error) {
-	var timeoutCtx context.Context
-	var timeoutCancel context.CancelFunc
-	var mu sync.Mutex
-
-	batchOpts := newBatchOptions(opts...)
-	if batchOpts.Timeout > 0 {
-		timeoutCtx, timeoutCancel = context.WithTimeout(context.Background(), time.Duration(batchOpts.Timeout)*time.Second)
-		defer timeoutCancel()
-	} else {
-		timeoutCtx = context.Background()
+	results := make([]mo.Result[T], len(tasks))
+	for i := range results {
+		results[i] = mo.Err[T](ErrTaskNotStarted)
 	}
+	for i, result := range BatchStream(c, tasks, opts...) {
+		results[i] = result
+	}
+	return results, nil
+}
 
-	results := make([]mo.Result[T], len(tasks))
+// batchResult is the result of the i-th task.
+type batchResult[T any] struct {
+	index  int
+	result mo.Result[T]
+}
 
-	g, ctx := errgroup.WithContext(timeoutCtx)
-	g.SetLimit(batchOpts.MaxConcurrency)
-	for i, task := range tasks {
-		g.Go(func() error {
-			result := task(c, ctx)
+// BatchStream runs the tasks concurrently and yields the index of each task and its result
+// as soon as the task completes, i.e. in the order of completion. Breaking out of the loop
+// (or the Context of the options being done) cancels the context of the running tasks and
+// doesn't start the remaining ones.
+func BatchStream[T any](c *Client, tasks []BatchTask[T], opts ...BatchOption) iter.Seq2[int, mo.Result[T]] {
+	return func(yield func(int, mo.Result[T]) bool) {
+		batchOpts := newBatchOptions(opts...)
+		parent := batchOpts.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		// stopCtx is canceled when the consumer stops the iteration or the parent context is done
+		stopCtx, stop := context.WithCancel(parent)
+		timeoutCtx := stopCtx
+
+		if batchOpts.Timeout > 0 {
+			var timeoutCancel context.CancelFunc
+			timeoutCtx, timeoutCancel = context.WithTimeout(stopCtx, time.Duration(batchOpts.Timeout)*time.Second)
+			defer timeoutCancel()
+		}
 
-			mu.Lock()
-			results[i] = result
-			mu.Unlock()
+		results := make(chan batchResult[T], max(batchOpts.MaxConcurrency, 0))
+		var wg sync.WaitGroup
 
-			return nil
+		defer func() {
+			// stop the running tasks and wait for them
+			stop()
+			for range results {
+				// drain the results of the running tasks
+			}
+			wg.Wait()
+		}()
+
+		wg.Go(func() {
+			defer close(results)
+
+			g, ctx := errgroup.WithContext(timeoutCtx)
+			g.SetLimit(batchOpts.MaxConcurrency)
+			for i, task := range tasks {
+				if stopCtx.Err() != nil {
+					break
+				}
+				g.Go(func() error {
+					// the consumer may stop while waiting for a free slot
+					if stopCtx.Err() != nil {
+						return nil
+					}
+					results <- batchResult[T]{index: i, result: runBatchTask(c, ctx, i, task, batchOpts)}
+					return nil
+				})
+			}
+			_ = g.Wait()
 		})
+
+		for r := range results {
+			if !yield(r.index, r.result) {
+				return
+			}
+		}
 	}
+}
 
-	err := g.Wait()
-	if err != nil {
-		return results, err
+// isRetryableTaskError reports whether a failed task is retried. timedOut is true if the
+// attempt hit the task timeout.
+func (p *RetryPolicy) isRetryableTaskError(err error, timedOut bool) bool {
+	if timedOut {
+		return true
+	}
+	// an exceeded quota is not reset by retrying right away
+	if errors.Is(err, ErrQuotaExceeded) {
+		return false
 	}
+	jsonErr, ok := asJSONError(err)
+	if ok {
+		return p.isRetryableStatus(jsonErr.Status)
+	}
+	return p.isRetryableError(err)
+}
 
-	return results, nil
+// runBatchTask runs the task with the per-task timeout and retry policy of opts.
+func runBatchTask[T any](c *Client, ctx context.Context, index int, task BatchTask[T], opts *BatchOptions) mo.Result[T] {
+	if opts.OnStart != nil {
+		opts.OnStart(index)
+	}
+
+	ctx, finish := c.telemetry.startSpan(ctx, "urlscan.batch.task", attribute.Int("urlscan.batch.task.index", index))
+	var result mo.Result[T]
+	attempt := 1
+	for ; ; attempt++ {
+		taskCtx, cancel := ctx, context.CancelFunc(func() {})
+		if opts.TaskTimeout > 0 {
+			taskCtx, cancel = context.WithTimeout(ctx, time.Duration(opts.TaskTimeout)*time.Second)
+		}
+		result = task(c, taskCtx)
+		timedOut := ctx.Err() == nil && errors.Is(taskCtx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		err := result.Error()
+		if err != nil && timedOut {
+			err = fmt.Errorf("task timed out after %ds: %w", opts.TaskTimeout, err)
+			result = mo.Err[T](err)
+		}
+
+		policy := opts.RetryPolicy
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.isRetryableTaskError(err, timedOut) {
+			break
+		}
+
+		delay := policy.backoff(attempt)
+		c.Logger().Info(fmt.Sprintf("Task failed, retrying in %s", delay), "index", index, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			// keep the result of the last attempt
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	finish(result.Error(), attribute.Int("urlscan.batch.task.attempts", attempt))
+	if opts.OnDone != nil {
+		opts.OnDone(index, result.Error())
+	}
+	return result
 }
 
 func BatchResultToRaw(r mo.Result[*Response]) *json.RawMessage {
 	err := r.Error()
 	if err != nil {
-		jsonErr, ok := errors.AsType[*JSONError](err)
-		if ok {
+		jsonErr, ok := asJSONError(err)
+		if ok && len(jsonErr.Raw) > 0 {
 			return &jsonErr.Raw
 		}
 		errRaw := json.RawMessage(fmt.Sprintf(`{"error": "%s"}`, err.Error()))
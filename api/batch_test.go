@@ -2,8 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/h2non/gock"
 	"github.com/samber/mo"
@@ -40,3 +45,170 @@ func TestBatch(t *testing.T) {
 	assert.Equal(t, results[0].MustGet().StatusCode, http.StatusOK)
 	assert.Equal(t, results[1].MustGet().StatusCode, http.StatusOK)
 }
+
+func TestBatchStream(t *testing.T) {
+	c := newTestClient()
+
+	// the tasks complete in the reverse order
+	release := []chan struct{}{make(chan struct{}), make(chan struct{}), make(chan struct{})}
+	tasks := make([]BatchTask[int], len(release))
+	for i := range tasks {
+		tasks[i] = func(c *Client, ctx context.Context) mo.Result[int] {
+			<-release[i]
+			if i+1 < len(release) {
+				close(release[i+1])
+			}
+			return mo.Ok(i * 10)
+		}
+	}
+	close(release[0])
+
+	var indexes []int
+	for i, result := range BatchStream(c, tasks, WithBatchMaxConcurrency(3)) {
+		indexes = append(indexes, i)
+		assert.Equal(t, i*10, result.MustGet())
+	}
+	assert.Equal(t, []int{0, 1, 2}, indexes)
+}
+
+func TestBatchStreamBreak(t *testing.T) {
+	c := newTestClient()
+
+	var started atomic.Int32
+	tasks := make([]BatchTask[int], 10)
+	for i := range tasks {
+		tasks[i] = func(c *Client, ctx context.Context) mo.Result[int] {
+			started.Add(1)
+			if i == 0 {
+				return mo.Ok(i)
+			}
+			// the other tasks run until they are canceled
+			<-ctx.Done()
+			return mo.Err[int](ctx.Err())
+		}
+	}
+
+	for i := range BatchStream(c, tasks, WithBatchMaxConcurrency(2)) {
+		assert.Equal(t, 0, i)
+		break
+	}
+	// the remaining tasks are not started after the break
+	assert.LessOrEqual(t, started.Load(), int32(3))
+}
+
+func TestBatchTaskRetry(t *testing.T) {
+	c := newTestClient()
+
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 3
+	policy.BaseDelay = time.Millisecond
+
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int32
+	}{
+		{name: "retryable status", err: &JSONError{Status: http.StatusServiceUnavailable}, wantAttempts: 3},                                                 //nolint:exhaustruct
+		{name: "not retryable status", err: &JSONError{Status: http.StatusBadRequest}, wantAttempts: 1},                                                     //nolint:exhaustruct
+		{name: "quota exceeded", err: &JSONError{Status: http.StatusTooManyRequests, Header: http.Header{"X-Rate-Limit-Window": {"day"}}}, wantAttempts: 1}, //nolint:exhaustruct
+		{name: "network error", err: io.ErrUnexpectedEOF, wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			tasks := []BatchTask[int]{func(c *Client, ctx context.Context) mo.Result[int] {
+				attempts.Add(1)
+				return mo.Err[int](tt.err)
+			}}
+
+			results, err := Batch(c, tasks, WithBatchMaxConcurrency(1), WithBatchRetryPolicy(policy))
+			assert.NoError(t, err)
+			assert.ErrorIs(t, results[0].Error(), tt.err)
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestBatchTaskTimeoutAndHooks(t *testing.T) {
+	c := newTestClient()
+
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 2
+	policy.BaseDelay = time.Millisecond
+
+	var attempts atomic.Int32
+	tasks := []BatchTask[int]{
+		func(c *Client, ctx context.Context) mo.Result[int] {
+			// the first attempt hits the task timeout
+			if attempts.Add(1) == 1 {
+				<-ctx.Done()
+				return mo.Err[int](ctx.Err())
+			}
+			return mo.Ok(1)
+		},
+		func(c *Client, ctx context.Context) mo.Result[int] {
+			<-ctx.Done()
+			return mo.Err[int](ctx.Err())
+		},
+	}
+
+	var mu sync.Mutex
+	started := make([]int, 0)
+	done := make(map[int]error)
+	results, err := Batch(c, tasks,
+		WithBatchMaxConcurrency(2),
+		WithBatchTaskTimeout(1),
+		WithBatchRetryPolicy(policy),
+		WithBatchOnStart(func(index int) {
+			mu.Lock()
+			defer mu.Unlock()
+			started = append(started, index)
+		}),
+		WithBatchOnDone(func(index int, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			done[index] = err
+		}),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, results[0].MustGet())
+	assert.ErrorIs(t, results[1].Error(), context.DeadlineExceeded)
+	assert.ErrorContains(t, results[1].Error(), "task timed out after 1s")
+
+	assert.ElementsMatch(t, []int{0, 1}, started)
+	assert.NoError(t, done[0])
+	assert.Error(t, done[1])
+}
+
+func TestBatchContext(t *testing.T) {
+	c := newTestClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tasks := make([]BatchTask[int], 5)
+	for i := range tasks {
+		tasks[i] = func(c *Client, taskCtx context.Context) mo.Result[int] {
+			if i == 0 {
+				// stop the batch while the first tasks are running
+				cancel()
+			}
+			<-taskCtx.Done()
+			return mo.Err[int](taskCtx.Err())
+		}
+	}
+
+	results, err := Batch(c, tasks, WithBatchMaxConcurrency(2), WithBatchContext(ctx))
+	assert.NoError(t, err)
+	assert.Len(t, results, 5)
+
+	notStarted := 0
+	for _, result := range results {
+		assert.Error(t, result.Error())
+		if errors.Is(result.Error(), ErrTaskNotStarted) {
+			notStarted++
+		}
+	}
+	assert.GreaterOrEqual(t, notStarted, 3)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultCacheMaxEntrySize = 32 * 1024 * 1024
+
+// CacheStore is a key-value store for cached responses.
+type CacheStore interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// CacheRule makes GET responses whose URL path matches Pattern cacheable.
+type CacheRule struct {
+	Pattern *regexp.Regexp
+	// TTL is the lifetime of a cached response. Zero means the resource is immutable and never expires.
+	TTL time.Duration
+}
+
+// DefaultCacheRules returns the rules for immutable resources (scan results, DOM snapshots,
+// screenshots and responses). Search and hostname lookups are cached for mutableTTL
+// if it's greater than zero.
+func DefaultCacheRules(mutableTTL time.Duration) []CacheRule {
+	rules := []CacheRule{
+		{Pattern: regexp.MustCompile(`^/api/v1/result/[0-9a-f-]{36}/?$`), TTL: 0},
+		{Pattern: regexp.MustCompile(`^/dom/[0-9a-f-]{36}/?$`), TTL: 0},
+		{Pattern: regexp.MustCompile(`^/screenshots/[0-9a-f-]{36}\.png$`), TTL: 0},
+		{Pattern: regexp.MustCompile(`^/responses/[0-9a-f]{64}/?$`), TTL: 0},
+	}
+	if mutableTTL > 0 {
+		rules = append(rules,
+			CacheRule{Pattern: regexp.MustCompile(`^/api/v1/search/?$`), TTL: mutableTTL},
+			CacheRule{Pattern: regexp.MustCompile(`^/api/v1/hostname/[^/]+/?$`), TTL: mutableTTL},
+		)
+	}
+	return rules
+}
+
+type cacheEntry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	ExpiresAt  time.Time
+}
+
+// Cache caches successful GET responses matching Rules in Store.
+type Cache struct {
+	Store CacheStore
+	Rules []CacheRule
+	// MaxEntrySize is the maximum body size of a cacheable response.
+	MaxEntrySize int64
+	// Logger is the logger for cache messages. The package default logger is used if it's nil.
+	Logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewCache(store CacheStore) *Cache {
+	return &Cache{
+		Store:        store,
+		Rules:        DefaultCacheRules(0),
+		MaxEntrySize: DefaultCacheMaxEntrySize,
+		Logger:       nil,
+		now:          time.Now,
+	}
+}
+
+func (c *Cache) ttl(path string) (time.Duration, bool) {
+	for _, rule := range c.Rules {
+		if rule.Pattern.MatchString(path) {
+			return rule.TTL, true
+		}
+	}
+	return 0, false
+}
+
+// cacheKey returns the cache key of req. The key includes a hash of the API key
+// since the visibility of a resource (e.g. a private scan) depends on it.
+func cacheKey(req *http.Request) string {
+	return fmt.Sprintf("%s %s %s", req.Method, req.URL.String(), keyFingerprint(req.Header.Get("API-Key")))
+}
+
+func (c *Cache) get(key string) (*cacheEntry, bool) {
+	b, ok, err := c.Store.Get(key)
+	if err != nil {
+		loggerOrDefault(c.Logger).Warn("Failed to read the cache", "key", key, "error", err.Error())
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&entry)
+	if err != nil {
+		return nil, false
+	}
+	if !entry.ExpiresAt.IsZero() && c.now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *Cache) set(key string, entry *cacheEntry) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(entry)
+	if err == nil {
+		err = c.Store.Set(key, buf.Bytes())
+	}
+	if err != nil {
+		loggerOrDefault(c.Logger).Warn("Failed to write the cache", "key", key, "error", err.Error())
+	}
+}
+
+func (c *Cache) roundTrip(transport http.RoundTripper, req *http.Request) (*http.Response, error) {
+	// range requests are used for resuming downloads and partial bodies are never cached
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return transport.RoundTrip(req)
+	}
+	ttl, ok := c.ttl(apiPath(req))
+	if !ok {
+		return transport.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	entry, ok := c.get(key)
+	if ok {
+		loggerOrDefault(c.Logger).Debug("Cache hit", "url", req.URL.String())
+		header := entry.Header.Clone()
+		header.Set("Content-Length", strconv.Itoa(len(entry.Body)))
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+			StatusCode:    entry.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+
+	maxEntrySize := c.MaxEntrySize
+	if maxEntrySize <= 0 {
+		maxEntrySize = DefaultCacheMaxEntrySize
+	}
+	if res.ContentLength > maxEntrySize {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxEntrySize+1))
+	if err != nil || int64(len(body)) > maxEntrySize {
+		// too large (or failed) to cache, hand back the rest of the body as is
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	err = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := res.Header.Clone()
+	header.Del("Set-Cookie")
+	// a stale rate limit state would put a key of the key pool on cooldown on a cache hit
+	for k := range header {
+		if strings.HasPrefix(k, "X-Rate-Limit-") {
+			header.Del(k)
+		}
+	}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+	c.set(key, &cacheEntry{
+		StatusCode: res.StatusCode,
+		Header:     header,
+		Body:       body,
+		ExpiresAt:  expiresAt,
+	})
+
+	return res, nil
+}
+
+// CacheTransport serves cacheable responses from Cache. It passes requests through if Cache is nil.
+type CacheTransport struct {
+	Transport http.RoundTripper
+	Cache     *Cache
+}
+
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Cache == nil {
+		return t.Transport.RoundTrip(req)
+	}
+	return t.Cache.roundTrip(t.Transport, req)
+}
+
+func (t *CacheTransport) setLogger(logger *slog.Logger) {
+	if t.Cache != nil {
+		t.Cache.Logger = logger
+	}
+}
+
+// SetCache sets the response cache. The cache stage is beneath the key pool stage so
+// responses are cached by the key of the pool which was picked, and above the rate limit
+// stage so cache hits don't consume the rate limit. Pass nil to disable it.
+func (c *Client) SetCache(cache *Cache) *Client {
+	if cache == nil {
+		c.stage(stageCache).set(nil)
+		return c
+	}
+	if cache.Logger == nil {
+		cache.Logger = c.logger
+	}
+	c.stage(stageCache).set(CacheMiddleware(cache))
+	return c
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testCacheUUID = "68e26c59-2eae-437b-aeb1-cf750fafe7d7"
+)
+
+func newTestCache(t *testing.T) (*Cache, *DiskCache) {
+	store := NewDiskCache(t.TempDir(), 0)
+	return NewCache(store), store
+}
+
+func TestCacheImmutable(t *testing.T) {
+	defer gock.Off()
+
+	path := fmt.Sprintf("/api/v1/result/%s/", testCacheUUID)
+	gock.New("http://testserver/").Get(path).Times(1).Reply(200).JSON(map[string]string{"foo": "bar"})
+
+	c := newTestClient()
+	cache, store := newTestCache(t)
+	c.SetCache(cache)
+
+	for range 3 {
+		resp, err := c.NewRequest().Get(path)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.JSONEq(t, `{"foo":"bar"}`, string(resp.body))
+	}
+	assert.True(t, gock.IsDone())
+
+	stats, err := store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Entries)
+
+	// a different API key doesn't share the cache
+	gock.New("http://testserver/").Get(path).Times(1).Reply(200).JSON(map[string]string{"foo": "baz"})
+	resp, err := c.SetAPIKey("another").NewRequest().Get(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"foo":"baz"}`, string(resp.body))
+	assert.True(t, gock.IsDone())
+}
+
+func TestCacheBaseURLPrefix(t *testing.T) {
+	defer gock.Off()
+
+	path := fmt.Sprintf("/api/v1/result/%s/", testCacheUUID)
+	gock.New("http://testserver/").Get("/prefix" + path).Times(1).Reply(200).JSON(map[string]string{"foo": "bar"})
+
+	baseURL, err := ParseBaseURL("http://testserver/prefix")
+	assert.NoError(t, err)
+	c := newTestClient().SetBaseURL(baseURL)
+	cache, _ := newTestCache(t)
+	c.SetCache(cache)
+
+	// the cache rules match the path without the prefix of the base URL
+	for range 2 {
+		_, err := c.NewRequest().Get(path)
+		assert.NoError(t, err)
+	}
+	assert.True(t, gock.IsDone())
+}
+
+func TestCacheSkipsErrorsAndUncacheable(t *testing.T) {
+	defer gock.Off()
+
+	path := fmt.Sprintf("/api/v1/result/%s/", testCacheUUID)
+	gock.New("http://testserver/").Get(path).Times(1).Reply(404).JSON(map[string]any{"status": 404, "message": "not found"})
+	gock.New("http://testserver/").Get(path).Times(1).Reply(200).JSON(map[string]string{"foo": "bar"})
+	gock.New("http://testserver/").Get("/api/v1/search/").Times(1).Reply(200).JSON(map[string]string{"foo": "bar"})
+	gock.New("http://testserver/").Get("/api/v1/search/").Times(1).Reply(200).JSON(map[string]string{"foo": "baz"})
+
+	c := newTestClient()
+	cache, _ := newTestCache(t)
+	c.SetCache(cache)
+
+	_, err := c.NewRequest().Get(path)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.NewRequest().Get(path)
+	assert.NoError(t, err)
+
+	// search is not cached without TTL
+	for range 2 {
+		_, err := c.NewRequest().Get("/api/v1/search/")
+		assert.NoError(t, err)
+	}
+	assert.True(t, gock.IsDone())
+}
+
+func TestCacheTTL(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").Get("/api/v1/search/").Times(1).Reply(200).JSON(map[string]string{"foo": "bar"})
+	gock.New("http://testserver/").Get("/api/v1/search/").Times(1).Reply(200).JSON(map[string]string{"foo": "baz"})
+
+	c := newTestClient()
+	cache, _ := newTestCache(t)
+	cache.Rules = DefaultCacheRules(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	c.SetCache(cache)
+
+	for range 2 {
+		resp, err := c.NewRequest().SetQueryParam("q", "foo").Get("/api/v1/search/")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"foo":"bar"}`, string(resp.body))
+	}
+
+	// expired
+	now = now.Add(2 * time.Minute)
+	resp, err := c.NewRequest().SetQueryParam("q", "foo").Get("/api/v1/search/")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"foo":"baz"}`, string(resp.body))
+	assert.True(t, gock.IsDone())
+}
+
+func TestCacheMaxEntrySize(t *testing.T) {
+	defer gock.Off()
+
+	path := fmt.Sprintf("/dom/%s/", testCacheUUID)
+	body := strings.Repeat("a", 100)
+	gock.New("http://testserver/").Get(path).Times(2).Reply(200).BodyString(body)
+
+	c := newTestClient()
+	cache, store := newTestCache(t)
+	cache.MaxEntrySize = 10
+	c.SetCache(cache)
+
+	for range 2 {
+		resp, err := c.NewRequest().Get(path)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(resp.body))
+	}
+	assert.True(t, gock.IsDone())
+
+	stats, err := store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	store := NewDiskCache(t.TempDir(), 25)
+
+	assert.NoError(t, store.Set("a", []byte(strings.Repeat("a", 10))))
+	assert.NoError(t, store.Set("b", []byte(strings.Repeat("b", 10))))
+	// make "b" the least recently used
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(store.path("b"), past, past))
+
+	assert.NoError(t, store.Set("c", []byte(strings.Repeat("c", 10))))
+
+	_, ok, _ := store.Get("b")
+	assert.False(t, ok)
+	_, ok, _ = store.Get("a")
+	assert.True(t, ok)
+	_, ok, _ = store.Get("c")
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Clear())
+	stats, err := store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+	assert.Equal(t, int64(0), stats.Size)
+}
+
+func TestSetCache(t *testing.T) {
+	c := NewClient("dummy")
+	cache := NewCache(NewDiskCache(t.TempDir(), 0))
+	c.SetCache(cache)
+
+	// retry -> cache -> rate limit
+	assert.Equal(t, []string{stageRetry, stageCache, stageRateLimit}, activeStages(c))
+	cacheTransport, ok := c.stage(stageCache).handler.(*CacheTransport)
+	assert.True(t, ok)
+	assert.Equal(t, cache, cacheTransport.Cache)
+
+	// replace the rate limiter in place
+	limiter := NewRateLimiter()
+	c.SetRateLimiter(limiter)
+	rateLimitTransport, ok := c.stage(stageRateLimit).handler.(*RateLimitTransport)
+	assert.True(t, ok)
+	assert.Equal(t, limiter, rateLimitTransport.Limiter)
+	assert.Equal(t, []string{stageRetry, stageCache, stageRateLimit}, activeStages(c))
+
+	// disable
+	c.SetCache(nil)
+	assert.Equal(t, []string{stageRetry, stageRateLimit}, activeStages(c))
+}
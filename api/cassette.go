@@ -0,0 +1,361 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type CassetteMode int
+
+const (
+	CassetteRecord CassetteMode = iota
+	CassetteReplay
+)
+
+const cassetteExt = ".json"
+
+var ErrCassetteNotFound = errors.New("no recorded interaction found in the cassette")
+
+var cassetteSlugPattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+type CassetteBody struct {
+	// Encoding is "base64" for a binary body and empty for a UTF-8 text body.
+	Encoding string `json:"encoding,omitempty"`
+	Data     string `json:"data"`
+}
+
+func newCassetteBody(b []byte) CassetteBody {
+	if utf8.Valid(b) {
+		return CassetteBody{Encoding: "", Data: string(b)}
+	}
+	return CassetteBody{Encoding: "base64", Data: base64.StdEncoding.EncodeToString(b)}
+}
+
+func (b CassetteBody) Bytes() ([]byte, error) {
+	if b.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(b.Data)
+	}
+	return []byte(b.Data), nil
+}
+
+type CassetteRequest struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Header http.Header  `json:"header"`
+	Body   CassetteBody `json:"body"`
+}
+
+type CassetteResponse struct {
+	StatusCode int          `json:"statusCode"`
+	Header     http.Header  `json:"header"`
+	Body       CassetteBody `json:"body"`
+}
+
+// Interaction is a recorded request/response pair stored in a cassette file.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteTransport records HTTP interactions to cassette files in Dir or replays them.
+// Each interaction is stored in its own JSON file with sensitive headers (e.g. API-Key) scrubbed.
+// Requests are matched by the method, the path with the query and the body. Repeated requests
+// (e.g. polling a result) are replayed in the recorded order.
+type CassetteTransport struct {
+	Transport http.RoundTripper
+	Dir       string
+	Mode      CassetteMode
+	mu        sync.Mutex
+	seq       int
+	recorded  map[string][]*Interaction
+	replayed  map[string]int
+}
+
+// NewCassetteRecorder returns a transport which records interactions made through transport to dir.
+func NewCassetteRecorder(transport http.RoundTripper, dir string) *CassetteTransport {
+	return &CassetteTransport{
+		Transport: transport,
+		Dir:       dir,
+		Mode:      CassetteRecord,
+		mu:        sync.Mutex{},
+		seq:       -1,
+		recorded:  nil,
+		replayed:  make(map[string]int),
+	}
+}
+
+// NewCassetteReplayer returns a transport which replays interactions recorded in dir without network access.
+func NewCassetteReplayer(dir string) *CassetteTransport {
+	return &CassetteTransport{
+		Transport: nil,
+		Dir:       dir,
+		Mode:      CassetteReplay,
+		mu:        sync.Mutex{},
+		seq:       -1,
+		recorded:  nil,
+		replayed:  make(map[string]int),
+	}
+}
+
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	if scrubbed == nil {
+		scrubbed = make(http.Header)
+	}
+	for _, k := range sensitiveHeaders {
+		if scrubbed.Get(k) != "" {
+			scrubbed.Set(k, redacted)
+		}
+	}
+	return scrubbed
+}
+
+func interactionKey(method, rawURL string, body []byte) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s %s", method, u.RequestURI())
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		key += " " + hex.EncodeToString(sum[:8])
+	}
+	return key, nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close() //nolint:errcheck
+		return io.ReadAll(body)
+	}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// load reads the interactions recorded in Dir ordered by the file names.
+func (t *CassetteTransport) load() error {
+	if t.recorded != nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(t.Dir)
+	if err != nil {
+		return err
+	}
+
+	recorded := make(map[string][]*Interaction)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != cassetteExt {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(t.Dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		var interaction Interaction
+		err = json.Unmarshal(b, &interaction)
+		if err != nil {
+			return fmt.Errorf("invalid cassette file %s: %w", e.Name(), err)
+		}
+		body, err := interaction.Request.Body.Bytes()
+		if err != nil {
+			return fmt.Errorf("invalid cassette file %s: %w", e.Name(), err)
+		}
+		key, err := interactionKey(interaction.Request.Method, interaction.Request.URL, body)
+		if err != nil {
+			return fmt.Errorf("invalid cassette file %s: %w", e.Name(), err)
+		}
+		recorded[key] = append(recorded[key], &interaction)
+	}
+	t.recorded = recorded
+	return nil
+}
+
+// nextSeq returns the sequence number for the next cassette file, which continues from the existing files.
+func (t *CassetteTransport) nextSeq() (int, error) {
+	if t.seq < 0 {
+		entries, err := os.ReadDir(t.Dir)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range entries {
+			prefix, _, ok := strings.Cut(e.Name(), "-")
+			if !ok {
+				continue
+			}
+			n, err := strconv.Atoi(prefix)
+			if err == nil {
+				t.seq = max(t.seq, n)
+			}
+		}
+	}
+	t.seq++
+	return t.seq, nil
+}
+
+func (t *CassetteTransport) record(req *http.Request, reqBody []byte, res *http.Response) error {
+	resBody, err := io.ReadAll(res.Body)
+	closeErr := res.Body.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	interaction := Interaction{
+		Request: CassetteRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: scrubHeader(req.Header),
+			Body:   newCassetteBody(reqBody),
+		},
+		Response: CassetteResponse{
+			StatusCode: res.StatusCode,
+			Header:     scrubHeader(res.Header),
+			Body:       newCassetteBody(resBody),
+		},
+	}
+	b, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err = os.MkdirAll(t.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	seq, err := t.nextSeq()
+	if err != nil {
+		return err
+	}
+	slug := strings.Trim(cassetteSlugPattern.ReplaceAllString(req.URL.Path, "-"), "-")
+	name := fmt.Sprintf("%06d-%s-%s%s", seq, strings.ToLower(req.Method), slug, cassetteExt)
+	return os.WriteFile(filepath.Join(t.Dir, name), b, 0o644)
+}
+
+func (t *CassetteTransport) replay(req *http.Request, reqBody []byte) (*http.Response, error) {
+	key, err := interactionKey(req.Method, req.URL.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	err = t.load()
+	if err != nil {
+		t.mu.Unlock()
+		return nil, err
+	}
+	interactions := t.recorded[key]
+	n := t.replayed[key]
+	if n >= len(interactions) {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteNotFound, req.Method, req.URL.RequestURI())
+	}
+	t.replayed[key] = n + 1
+	t.mu.Unlock()
+
+	interaction := interactions[n]
+	body, err := interaction.Response.Body.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	statusCode := interaction.Response.StatusCode
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Mode == CassetteReplay {
+		return t.replay(req, reqBody)
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	err = t.record(req, reqBody, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the interaction: %w", err)
+	}
+	return res, nil
+}
+
+// Remaining returns the number of recorded interactions which have not been replayed yet.
+func (t *CassetteTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.load() != nil {
+		return 0
+	}
+	remaining := 0
+	for key, interactions := range t.recorded {
+		remaining += len(interactions) - t.replayed[key]
+	}
+	return remaining
+}
+
+// SetCassette records or replays interactions with the cassette transport. The cassette
+// stage is above the retry and rate limit stages, so an interaction is the outcome of
+// a request after retries and a replayed interaction (e.g. a 429) is returned as is
+// without retrying or pacing. Pass nil to remove it.
+func (c *Client) SetCassette(cassette *CassetteTransport) *Client {
+	if cassette == nil {
+		c.stage(stageCassette).set(nil)
+		return c
+	}
+	c.stage(stageCassette).set(CassetteMiddleware(cassette))
+	return c
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	gock.New("http://testserver/").Get("/api/v1/result/dummy/").Times(1).Reply(404).JSON(map[string]any{"status": 404, "message": "not found"})
+	gock.New("http://testserver/").Get("/api/v1/result/dummy/").Times(1).Reply(200).JSON(map[string]string{"foo": "bar"})
+	gock.New("http://testserver/").Post("/api/v1/scan/").Times(1).Reply(200).JSON(map[string]string{"uuid": "dummy"})
+	gock.New("http://testserver/").Get("/screenshots/dummy.png").Times(1).Reply(200).Body(bytes.NewReader([]byte{0x89, 'P', 'N', 'G', 0xff}))
+
+	c := newTestClient().SetAPIKey("secret-api-key")
+	c.SetCassette(NewCassetteRecorder(nil, dir))
+
+	_, err := c.NewRequest().Get("/api/v1/result/dummy/")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.NewRequest().Get("/api/v1/result/dummy/")
+	assert.NoError(t, err)
+	_, err = c.NewRequest().SetBodyJSONBytes([]byte(`{"url":"https://example.com"}`)).Post("/api/v1/scan/")
+	assert.NoError(t, err)
+	_, err = c.NewRequest().Get("/screenshots/dummy.png")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+	gock.Off()
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 4)
+	assert.Equal(t, "000000-get-api-v1-result-dummy.json", files[0].Name())
+	// the API key is scrubbed
+	for _, f := range files {
+		b, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(b), "secret-api-key")
+		assert.Contains(t, string(b), redacted)
+	}
+
+	// replay without network access (gock is off and no mock is registered)
+	c = newTestClient()
+	replayer := NewCassetteReplayer(dir)
+	c.SetCassette(replayer)
+	assert.Equal(t, 4, replayer.Remaining())
+
+	_, err = c.NewRequest().Get("/api/v1/result/dummy/")
+	assert.ErrorIs(t, err, ErrNotFound)
+	resp, err := c.NewRequest().Get("/api/v1/result/dummy/")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"foo":"bar"}`, string(resp.body))
+	resp, err = c.NewRequest().SetBodyJSONBytes([]byte(`{"url":"https://example.com"}`)).Post("/api/v1/scan/")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"uuid":"dummy"}`, string(resp.body))
+	resp, err = c.NewRequest().Get("/screenshots/dummy.png")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G', 0xff}, resp.body)
+	assert.Equal(t, 0, replayer.Remaining())
+
+	// a different body or an exhausted request doesn't match
+	_, err = c.NewRequest().SetBodyJSONBytes([]byte(`{"url":"https://example.org"}`)).Post("/api/v1/scan/")
+	assert.ErrorIs(t, err, ErrCassetteNotFound)
+	_, err = c.NewRequest().Get("/api/v1/result/dummy/")
+	assert.ErrorIs(t, err, ErrCassetteNotFound)
+}
+
+func TestSetCassetteWithDebug(t *testing.T) {
+	c := NewClient("dummy")
+	cassette := NewCassetteReplayer(t.TempDir())
+	c.SetCassette(cassette)
+	c.SetDebugHTTP(os.Stderr, 0)
+
+	// cassette -> retry -> rate limit -> debug
+	assert.Equal(t, []string{stageCassette, stageRetry, stageRateLimit, stageDebug}, activeStages(c))
+	assert.Same(t, cassette, c.stage(stageCassette).handler)
+
+	c.SetCassette(nil)
+	assert.Equal(t, []string{stageRetry, stageRateLimit, stageDebug}, activeStages(c))
+}
+
+func TestCassetteReplayRateLimitedWithoutSleeping(t *testing.T) {
+	dir := t.TempDir()
+
+	gock.New("http://testserver/").Get("/api/v1/result/dummy/").Times(1).
+		Reply(http.StatusTooManyRequests).
+		SetHeader("X-Rate-Limit-Action", "retrieve").
+		SetHeader("X-Rate-Limit-Limit", "1").
+		SetHeader("X-Rate-Limit-Remaining", "0").
+		SetHeader("X-Rate-Limit-Reset-After", "60").
+		SetHeader("X-Rate-Limit-Window", "minute").
+		JSON(map[string]any{"status": 429, "message": "Rate limit exceeded"})
+	gock.New("http://testserver/").Get("/api/v1/result/dummy/").Times(1).
+		Reply(http.StatusTooManyRequests).
+		SetHeader("X-Rate-Limit-Reset-After", "60").
+		JSON(map[string]any{"status": 429, "message": "Rate limit exceeded"})
+
+	c := newTestClient().SetRetryPolicy(&RetryPolicy{MaxAttempts: 1}).SetRateLimiter(nil) //nolint:exhaustruct
+	c.SetCassette(NewCassetteRecorder(nil, dir))
+	for range 2 {
+		_, err := c.NewRequest().Get("/api/v1/result/dummy/")
+		assert.ErrorIs(t, err, ErrRateLimited)
+	}
+	assert.True(t, gock.IsDone())
+	gock.Off()
+
+	// neither the retry stage waits for the reset nor the rate limiter paces the second request
+	c = newTestClient()
+	c.SetCassette(NewCassetteReplayer(dir))
+	start := time.Now()
+	for range 2 {
+		_, err := c.NewRequest().Get("/api/v1/result/dummy/")
+		assert.ErrorIs(t, err, ErrRateLimited)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+}
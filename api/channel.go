@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -123,7 +124,16 @@ func newChannelOptions(opts ...ChannelOption) (*ChannelOptions, error) {
 	return &o, nil
 }
 
+// ListChannels returns an iterator over the channels of the current user.
+func (c *Client) ListChannels(opts ...IteratorOption) (*PageIterator[json.RawMessage], error) {
+	return newIterator(c, PrefixedPath("/user/channels/"), Paginator[json.RawMessage](ListPaginator[json.RawMessage]{Field: "channels"}), opts...)
+}
+
 func (c *Client) CreateChannel(opts ...ChannelOption) (*Response, error) {
+	return c.CreateChannelContext(context.Background(), opts...)
+}
+
+func (c *Client) CreateChannelContext(ctx context.Context, opts ...ChannelOption) (*Response, error) {
 	channelOpts, err := newChannelOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -134,12 +144,16 @@ func (c *Client) CreateChannel(opts ...ChannelOption) (*Response, error) {
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Post(
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Post(
 		PrefixedPath("/user/channels/"),
 	)
 }
 
 func (c *Client) UpdateChannel(id string, opts ...ChannelOption) (*Response, error) {
+	return c.UpdateChannelContext(context.Background(), id, opts...)
+}
+
+func (c *Client) UpdateChannelContext(ctx context.Context, id string, opts ...ChannelOption) (*Response, error) {
 	channelOpts, err := newChannelOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -150,7 +164,7 @@ func (c *Client) UpdateChannel(id string, opts ...ChannelOption) (*Response, err
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Put(
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Put(
 		PrefixedPath(fmt.Sprintf("/user/channels/%s", id)),
 	)
 }
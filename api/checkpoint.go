@@ -0,0 +1,16 @@
+package api
+
+// Checkpoint is the state of an iterator after a page of results was consumed.
+// Passing it back with IteratorResume (or HostnameIteratorResume) continues the
+// iteration from the next page.
+type Checkpoint struct {
+	// Cursor is the search_after of the search API or the pageState of the hostname API.
+	Cursor  string `json:"cursor"`
+	Count   int    `json:"count"`
+	Total   int    `json:"total"`
+	HasMore bool   `json:"hasMore"`
+}
+
+// CheckpointFunc is called after every page of results is consumed. The iteration stops
+// with the returned error if it's not nil.
+type CheckpointFunc func(Checkpoint) error
@@ -2,64 +2,21 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
-	"strconv"
-	"time"
+	"strings"
 )
 
 const (
 	version = "0.1.0"
 )
 
-var log = slog.New(slog.NewTextHandler(os.Stderr, nil))
-
-var baseURL = url.URL{
-	Scheme: "https",
-	Host:   "urlscan.io",
-}
-
-type RetryTransport struct {
-	Transport http.RoundTripper
-}
-
-func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	res, err := t.Transport.RoundTrip(req)
-	if err == nil && res.StatusCode == http.StatusTooManyRequests {
-		// rate limit headers: https://urlscan.io/docs/api/#ratelimit
-		limitAction := res.Header.Get("X-Rate-Limit-Action")
-		limitLimit := res.Header.Get("X-Rate-Limit-Limit")
-		limitReset := res.Header.Get("X-Rate-Limit-Reset")
-		limitScope := res.Header.Get("X-Rate-Limit-Scope")
-		limitWindow := res.Header.Get("X-Rate-Limit-Window")
-		retryAfter := res.Header.Get("X-Rate-Limit-Reset-After")
-
-		if retryAfter != "" {
-			retryAfterInt, err := strconv.Atoi(retryAfter)
-			if err == nil {
-				log.Info(fmt.Sprintf("Sleeping for %s seconds", retryAfter),
-					"X-Rate-Limit-Action", limitAction,
-					"X-Rate-Limit-Limit", limitLimit,
-					"X-Rate-Limit-Reset-After", retryAfter,
-					"X-Rate-Limit-Reset", limitReset,
-					"X-Rate-Limit-Scope", limitScope,
-					"X-Rate-Limit-Window", limitWindow,
-				)
-				select {
-				case <-time.After(time.Duration(retryAfterInt) * time.Second):
-				case <-req.Context().Done():
-					return nil, req.Context().Err()
-				}
-			}
-		}
-		res, err = t.Transport.RoundTrip(req)
-	}
-	return res, err
-}
+// DefaultBaseURL is the base URL of a client unless it's set by SetBaseURL.
+const DefaultBaseURL = "https://urlscan.io"
 
 type Client struct {
 	APIKey     string
@@ -67,10 +24,34 @@ type Client struct {
 	Err        error
 	BaseURL    *url.URL
 	httpClient *http.Client
+	logger     *slog.Logger
+	// middlewares are the stages of the request pipeline (see the built-in stages)
+	middlewares        []*stage
+	disableCompression bool
+	telemetry          *telemetry
 }
 
-func SetHost(host string) {
-	baseURL.Host = host
+// ParseBaseURL parses the base URL of the API. It's a URL with the http or https scheme
+// and an optional path prefix (e.g. "http://127.0.0.1:8080" or "https://gateway.example.com/urlscan"),
+// or a host name (with an optional port) which is served over https.
+func ParseBaseURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL: unsupported scheme %q (http or https)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL: missing host: %s", s)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid base URL: query and fragment are not allowed: %s", s)
+	}
+	return u, nil
 }
 
 func (c *Client) SetBaseURL(url *url.URL) *Client {
@@ -78,18 +59,20 @@ func (c *Client) SetBaseURL(url *url.URL) *Client {
 	return c
 }
 
+// SetTransport sets the transport which sends the requests, beneath the built-in stages.
+// It's the same as SetHTTPTransport.
 func (c *Client) SetTransport(transport http.RoundTripper) *Client {
-	if c.httpClient == nil || c.httpClient.Transport == nil {
-		c.httpClient = &http.Client{}
-	}
-	c.httpClient.Transport = transport
-	return c
+	return c.SetHTTPTransport(transport)
 }
 
+// SetRetryTransport retries requests with the default retry policy.
 func (c *Client) SetRetryTransport() *Client {
-	c.SetTransport(&RetryTransport{
-		Transport: http.DefaultTransport,
-	})
+	return c.SetRetryPolicy(DefaultRetryPolicy())
+}
+
+// SetRetryPolicy sets the retry policy of the client. DefaultRetryPolicy is used if it's nil.
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) *Client {
+	c.stage(stageRetry).set(RetryMiddleware(policy, c.logger))
 	return c
 }
 
@@ -103,30 +86,64 @@ func (c *Client) SetAgent(agent string) *Client {
 	return c
 }
 
-func (c *Client) SetDisableCompression(disable bool) *Client {
-	if c.httpClient == nil {
-		c.httpClient = &http.Client{}
-	}
-	transport, ok := c.httpClient.Transport.(*http.Transport)
-	if ok {
-		transport.DisableCompression = disable
-	} else {
-		transport, ok := c.httpClient.Transport.(*RetryTransport)
+// SetRateLimiter sets the client-side rate limiter. The limiter is placed
+// beneath the retry stage so retried requests are paced as well. Pass nil to disable it.
+func (c *Client) SetRateLimiter(limiter *RateLimiter) *Client {
+	if limiter == nil {
+		c.stage(stageRateLimit).set(nil)
+		return c
+	}
+	if limiter.Logger == nil {
+		limiter.Logger = c.logger
+	}
+	c.stage(stageRateLimit).set(RateLimitMiddleware(limiter))
+	return c
+}
+
+// SetLogger sets the logger of the client and its built-in stages.
+// The package default logger (see SetDefaultLogger) is used if it's not set.
+func (c *Client) SetLogger(logger *slog.Logger) *Client {
+	c.logger = logger
+	for _, s := range c.middlewares {
+		setter, ok := s.handler.(loggerSetter)
 		if ok {
-			innerTransport, ok := transport.Transport.(*http.Transport)
-			if ok {
-				innerTransport.DisableCompression = disable
-			}
+			setter.setLogger(logger)
 		}
 	}
 	return c
 }
 
+func (c *Client) Logger() *slog.Logger {
+	return loggerOrDefault(c.logger)
+}
+
+// SetDisableCompression stops asking for (and transparently decompressing) gzip-compressed responses.
+func (c *Client) SetDisableCompression(disable bool) *Client {
+	c.disableCompression = disable
+	return c
+}
+
 func NewClient(APIKey string) *Client {
-	c := &Client{httpClient: &http.Client{}, BaseURL: &baseURL, APIKey: "", Agent: "", Err: nil}
+	defaultBaseURL, _ := url.Parse(DefaultBaseURL)
+	c := &Client{
+		httpClient:         &http.Client{},
+		BaseURL:            defaultBaseURL,
+		APIKey:             "",
+		Agent:              "",
+		Err:                nil,
+		logger:             nil,
+		middlewares:        nil,
+		disableCompression: false,
+		telemetry:          nil,
+	}
 	c.SetAPIKey(APIKey)
 	c.SetAgent(fmt.Sprintf("urlscan-go/%s", version))
+	c.newStages()
+	c.stage(stageUserAgent).set(c.userAgentMiddleware)
+	c.stage(stageAPIKey).set(c.apiKeyMiddleware)
+	c.stage(stageCompression).set(c.compressionMiddleware)
 	c.SetRetryTransport()
+	c.SetRateLimiter(NewRateLimiter())
 	return c
 }
 
@@ -149,16 +166,37 @@ func (c *Client) NewRequest() *Request {
 		Path:        "",
 		QueryParams: make(map[string]string),
 		RawRequest:  nil,
+		Stream:      false,
 	}
 }
 
+// URL resolves path against the base URL. An absolute path is put under the path prefix
+// of the base URL (if any) and an absolute URL is returned as is.
 func (c *Client) URL(path string) (*url.URL, error) {
-	url, err := url.Parse(path)
+	ref, err := url.Parse(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.BaseURL.ResolveReference(url), nil
+	prefix := strings.TrimSuffix(c.BaseURL.Path, "/")
+	if prefix != "" && !ref.IsAbs() && ref.Host == "" && strings.HasPrefix(ref.Path, "/") {
+		ref.Path = prefix + ref.Path
+		if ref.RawPath != "" {
+			ref.RawPath = strings.TrimSuffix(c.BaseURL.EscapedPath(), "/") + ref.RawPath
+		}
+	}
+
+	return c.BaseURL.ResolveReference(ref), nil
+}
+
+// basePathContextKey is the context key of the path prefix of the base URL.
+type basePathContextKey struct{}
+
+// apiPath returns the path of req without the path prefix of the base URL,
+// so the built-in stages (e.g. the cache rules) match the API path.
+func apiPath(req *http.Request) string {
+	prefix, _ := req.Context().Value(basePathContextKey{}).(string)
+	return strings.TrimPrefix(req.URL.Path, prefix)
 }
 
 func (c *Client) Do(r *Request) (resp *Response, err error) {
@@ -188,6 +226,10 @@ func (c *Client) Do(r *Request) (resp *Response, err error) {
 	if err != nil {
 		return resp, fmt.Errorf("error creating HTTP request: %w", err)
 	}
+	if r.GetBody != nil {
+		// make the body replayable for retries
+		req.GetBody = r.GetBody
+	}
 
 	// set headers
 	headers := r.Headers
@@ -195,8 +237,6 @@ func (c *Client) Do(r *Request) (resp *Response, err error) {
 		headers = make(http.Header)
 	}
 	req.Header = headers
-	req.Header.Set("User-Agent", c.Agent)
-	req.Header.Set("API-Key", c.APIKey)
 
 	// set query parameters
 	if r.QueryParams != nil {
@@ -208,13 +248,36 @@ func (c *Client) Do(r *Request) (resp *Response, err error) {
 	}
 
 	ctx := r.ctx
-	if ctx != nil {
-		req = req.WithContext(ctx)
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	if prefix := strings.TrimSuffix(c.BaseURL.Path, "/"); prefix != "" && strings.HasPrefix(url.Path, prefix+"/") {
+		ctx = context.WithValue(ctx, basePathContextKey{}, prefix)
 	}
+	req = req.WithContext(ctx)
+
+	ctx, finish := c.telemetry.startRequest(ctx, req, apiPath(req), int64(len(r.Body)))
+	defer func() {
+		finishErr := err
+		if finishErr == nil {
+			finishErr = resp.err
+		}
+		responseBytes := int64(len(resp.body))
+		if resp.body == nil && resp.Response != nil {
+			responseBytes = resp.ContentLength
+		}
+		finish(resp.Response, responseBytes, finishErr)
+	}()
+	req = req.WithContext(ctx)
 	r.RawRequest = req
 
-	resp.Response, resp.err = c.httpClient.Do(req)
+	resp.Response, resp.err = c.middlewares[0].RoundTrip(req)
 	if resp.err == nil && resp.StatusCode >= 200 {
+		// hand back the live body to the caller on streaming
+		if r.Stream && resp.IsSuccess() {
+			return
+		}
+
 		// set resp.body
 		_, err = resp.ToBytes()
 		if err != nil {
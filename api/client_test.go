@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"testing"
@@ -138,6 +139,7 @@ func TestJSONError(t *testing.T) {
 		Message:     "Bad Request",
 		Raw:         nil,
 		Description: "Dummy",
+		Header:      nil,
 	}
 	marshalled, err := json.Marshal(jsonErr)
 	assert.NoError(t, err)
@@ -182,3 +184,148 @@ func TestRetryContextTimeout(t *testing.T) {
 	_, err := c.NewRequest().SetContext(ctx).Get("/bar")
 	assert.ErrorIs(t, err, context.DeadlineExceeded)
 }
+
+func TestStream(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(200).
+		BodyString("streamed content")
+
+	gock.New("http://testserver/").
+		Get("/baz").
+		Reply(404).
+		JSON(map[string]any{"status": 404, "message": "Not Found"})
+
+	c := newTestClient()
+	resp, err := c.NewRequest().SetStream(true).Get("/bar")
+	assert.NoError(t, err)
+	// body is not buffered
+	assert.Nil(t, resp.body)
+
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, "streamed content", string(b))
+
+	// error responses are buffered
+	_, err = c.NewRequest().SetStream(true).Get("/baz")
+	assert.Error(t, err)
+	assert.Equal(t, "Not Found", err.Error())
+
+	assert.True(t, gock.IsDone())
+}
+
+func TestContextCancel(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Post("/api/v1/scan/").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"uuid": "dummy"})
+
+	gock.New("http://testserver/").
+		Get("/api/v1/result/dummy/").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		Reply(http.StatusOK).
+		JSON(map[string]any{"results": []any{}, "total": 0})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	c := newTestClient()
+	_, err := c.ScanContext(ctx, "http://localhost")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = c.GetResultContext(ctx, "dummy")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	it, err := c.Search("test", IteratorContext(ctx))
+	assert.NoError(t, err)
+	for _, err := range it.Iterate() {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+func TestParseBaseURL(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "urlscan.io", want: "https://urlscan.io"},
+		{input: "127.0.0.1:8080", want: "https://127.0.0.1:8080"},
+		{input: "http://127.0.0.1:8080", want: "http://127.0.0.1:8080"},
+		{input: "https://gateway.example.com/urlscan/", want: "https://gateway.example.com/urlscan/"},
+		{input: "ftp://example.com", wantErr: true},
+		{input: "http://", wantErr: true},
+		{input: "https://example.com/?q=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBaseURL(tt.input)
+		if tt.wantErr {
+			assert.Error(t, err, tt.input)
+			continue
+		}
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got.String())
+	}
+}
+
+func TestClientURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		path    string
+		want    string
+	}{
+		{baseURL: "https://urlscan.io", path: "/api/v1/search/", want: "https://urlscan.io/api/v1/search/"},
+		{baseURL: "http://127.0.0.1:8080", path: "/api/v1/search/", want: "http://127.0.0.1:8080/api/v1/search/"},
+		{baseURL: "https://gateway.example.com/urlscan", path: "/api/v1/search/", want: "https://gateway.example.com/urlscan/api/v1/search/"},
+		{baseURL: "https://gateway.example.com/urlscan/", path: "/api/v1/search/", want: "https://gateway.example.com/urlscan/api/v1/search/"},
+		{baseURL: "https://gateway.example.com/urlscan", path: "https://example.com/file", want: "https://example.com/file"},
+	}
+
+	for _, tt := range tests {
+		u, err := ParseBaseURL(tt.baseURL)
+		assert.NoError(t, err)
+
+		got, err := NewClient("dummy").SetBaseURL(u).URL(tt.path)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got.String())
+	}
+}
+
+func TestClientBaseURLIsPerClient(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://first.test").Get("/api/v1/quotas/").Reply(200).JSON(map[string]any{"host": "first"})
+	gock.New("http://second.test").Get("/prefix/api/v1/quotas/").Reply(200).JSON(map[string]any{"host": "second"})
+
+	first, err := ParseBaseURL("http://first.test")
+	assert.NoError(t, err)
+	second, err := ParseBaseURL("http://second.test/prefix")
+	assert.NoError(t, err)
+
+	c1 := NewClient("dummy").SetBaseURL(first)
+	c2 := NewClient("dummy").SetBaseURL(second)
+	// a new client still targets the default base URL
+	assert.Equal(t, DefaultBaseURL, NewClient("dummy").BaseURL.String())
+
+	for _, tt := range []struct {
+		client *Client
+		want   string
+	}{{client: c1, want: "first"}, {client: c2, want: "second"}} {
+		resp, err := tt.client.NewRequest().Get(PrefixedPath("/quotas/"))
+		assert.NoError(t, err)
+		var got map[string]string
+		assert.NoError(t, resp.Unmarshal(&got))
+		assert.Equal(t, tt.want, got["host"])
+	}
+	assert.True(t, gock.IsDone())
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -80,6 +81,10 @@ func (r *DataDumpList) UnmarshalJSON(data []byte) error {
 }
 
 func (c *Client) BulkGetDataDumpList(path string) (*DataDumpList, error) {
+	return c.BulkGetDataDumpListContext(context.Background(), path)
+}
+
+func (c *Client) BulkGetDataDumpListContext(ctx context.Context, path string) (*DataDumpList, error) {
 	files := []DataDumpFile{}
 
 	paths, err := expandPath(path)
@@ -87,7 +92,7 @@ func (c *Client) BulkGetDataDumpList(path string) (*DataDumpList, error) {
 		return nil, err
 	}
 	for _, p := range paths {
-		list, err := c.GetDataDumpList(p)
+		list, err := c.GetDataDumpListContext(ctx, p)
 		if err != nil {
 			return nil, err
 		}
@@ -104,12 +109,16 @@ func (c *Client) BulkGetDataDumpList(path string) (*DataDumpList, error) {
 }
 
 func (c *Client) GetDataDumpList(path string) (*DataDumpList, error) {
+	return c.GetDataDumpListContext(context.Background(), path)
+}
+
+func (c *Client) GetDataDumpListContext(ctx context.Context, path string) (*DataDumpList, error) {
 	path, err := url.JoinPath("/datadump/list/", path)
 	if err != nil {
 		return nil, err
 	}
 
-	req := c.NewRequest().SetPath(PrefixedPath(path)).SetMethod("GET")
+	req := c.NewRequest().SetContext(ctx).SetPath(PrefixedPath(path)).SetMethod("GET")
 	resp, err := req.Do()
 	if err != nil {
 		return nil, err
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultDebugMaxBodySize = 1024
+	redacted                = "[REDACTED]"
+)
+
+var sensitiveHeaders = []string{
+	"Api-Key",
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// DebugTransport dumps HTTP request/response pairs to Writer.
+// Sensitive headers such as API-Key are redacted and bodies are truncated to MaxBodySize bytes.
+type DebugTransport struct {
+	Transport   http.RoundTripper
+	Writer      io.Writer
+	MaxBodySize int
+	mu          sync.Mutex
+}
+
+func NewDebugTransport(transport http.RoundTripper, w io.Writer, maxBodySize int) *DebugTransport {
+	return &DebugTransport{
+		Transport:   transport,
+		Writer:      w,
+		MaxBodySize: maxBodySize,
+		mu:          sync.Mutex{},
+	}
+}
+
+func writeHeaders(buf *bytes.Buffer, prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		for _, v := range header[k] {
+			if slices.Contains(sensitiveHeaders, http.CanonicalHeaderKey(k)) {
+				v = redacted
+			}
+			fmt.Fprintf(buf, "%s %s: %s\n", prefix, k, v)
+		}
+	}
+}
+
+func (t *DebugTransport) writeBody(buf *bytes.Buffer, prefix string, body []byte, truncated bool) {
+	if len(body) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s\n", prefix)
+	for line := range strings.Lines(string(body)) {
+		fmt.Fprintf(buf, "%s %s", prefix, line)
+	}
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	if truncated {
+		fmt.Fprintf(buf, "%s [truncated at %d bytes]\n", prefix, t.MaxBodySize)
+	}
+}
+
+// peekBody reads up to MaxBodySize bytes from body and returns a reader
+// which replays the read bytes followed by the rest of body.
+func (t *DebugTransport) peekBody(body io.ReadCloser) ([]byte, bool, io.ReadCloser, error) {
+	if body == nil || body == http.NoBody || t.MaxBodySize <= 0 {
+		return nil, false, body, nil
+	}
+
+	peeked, err := io.ReadAll(io.LimitReader(body, int64(t.MaxBodySize)+1))
+	if err != nil {
+		return nil, false, body, err
+	}
+
+	restored := struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), body), body}
+
+	truncated := len(peeked) > t.MaxBodySize
+	if truncated {
+		peeked = peeked[:t.MaxBodySize]
+	}
+	return peeked, truncated, restored, nil
+}
+
+func (t *DebugTransport) requestBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil || t.MaxBodySize <= 0 {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close() //nolint:errcheck
+
+	peeked, truncated, _, err := t.peekBody(body)
+	if err != nil {
+		return nil, false
+	}
+	return peeked, truncated
+}
+
+func (t *DebugTransport) write(buf *bytes.Buffer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.Writer.Write(buf.Bytes())
+}
+
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "> %s %s\n", req.Method, req.URL.String())
+	writeHeaders(&buf, ">", req.Header)
+	body, truncated := t.requestBody(req)
+	t.writeBody(&buf, ">", body, truncated)
+
+	start := time.Now()
+	res, err := t.Transport.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		fmt.Fprintf(&buf, "< error: %s (%s)\n\n", err, elapsed)
+		t.write(&buf)
+		return res, err
+	}
+
+	fmt.Fprintf(&buf, "< %s %s (%s)\n", res.Proto, res.Status, elapsed)
+	writeHeaders(&buf, "<", res.Header)
+
+	body, truncated, restored, peekErr := t.peekBody(res.Body)
+	if peekErr == nil {
+		res.Body = restored
+		t.writeBody(&buf, "<", body, truncated)
+	}
+	buf.WriteString("\n")
+	t.write(&buf)
+
+	return res, err
+}
+
+// SetDebugHTTP dumps HTTP request/response pairs sent over the wire to w. The debug stage is
+// beneath the retry stage so each attempt of retries is dumped.
+// Bodies are truncated to maxBodySize bytes. Pass a nil w to disable it.
+func (c *Client) SetDebugHTTP(w io.Writer, maxBodySize int) *Client {
+	if w == nil {
+		c.stage(stageDebug).set(nil)
+		return c
+	}
+	c.stage(stageDebug).set(DebugMiddleware(w, maxBodySize))
+	return c
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugHTTP(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Post("/bar").
+		MatchParam("foo", "bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"bar": strings.Repeat("x", 100)})
+
+	var buf bytes.Buffer
+	c := newTestClient().SetDebugHTTP(&buf, 16)
+	resp, err := c.NewRequest().
+		SetQueryParam("foo", "bar").
+		SetBodyJSONBytes([]byte(`{"foo":"bar"}`)).
+		Post("/bar")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	// the response body is not affected by the dump
+	assert.Equal(t, `{"bar":"`+strings.Repeat("x", 100)+"\"}\n", string(resp.body))
+
+	dump := buf.String()
+	assert.Contains(t, dump, "> POST http://testserver/bar?foo=bar\n")
+	assert.Contains(t, dump, "> Api-Key: [REDACTED]\n")
+	assert.NotContains(t, dump, "dummy")
+	assert.Contains(t, dump, `> {"foo":"bar"}`)
+	assert.Contains(t, dump, "< HTTP/1.1 200 OK (")
+	assert.Contains(t, dump, "< Content-Type: application/json\n")
+	assert.Contains(t, dump, `< {"bar":"xxxxxxxx`+"\n")
+	assert.Contains(t, dump, "< [truncated at 16 bytes]\n")
+}
+
+func TestDebugHTTPDisable(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestClient().SetDebugHTTP(&buf, 16)
+	c.SetDebugHTTP(nil, 0)
+
+	assert.NotContains(t, activeStages(c), stageDebug)
+}
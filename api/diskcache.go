@@ -0,0 +1,186 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DiskCache is a CacheStore which stores each entry as a file in Dir.
+// The least recently used entries are evicted when the total size exceeds MaxSize.
+type DiskCache struct {
+	Dir string
+	// MaxSize is the maximum total size of the entries in bytes. Zero means unlimited.
+	MaxSize int64
+	mu      sync.Mutex
+}
+
+type DiskCacheStats struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"`
+	Size    int64  `json:"size"`
+	MaxSize int64  `json:"maxSize"`
+}
+
+func NewDiskCache(dir string, maxSize int64) *DiskCache {
+	return &DiskCache{
+		Dir:     dir,
+		MaxSize: maxSize,
+		mu:      sync.Mutex{},
+	}
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool, error) {
+	path := d.path(key)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// bump the modification time to track the recent use
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return b, true, nil
+}
+
+func (d *DiskCache) Set(key string, value []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := os.MkdirAll(d.Dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see a partial entry
+	f, err := os.CreateTemp(d.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(value)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), d.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return d.evict()
+}
+
+func (d *DiskCache) Delete(key string) error {
+	err := os.Remove(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (d *DiskCache) entries() ([]fs.FileInfo, error) {
+	dirEntries, err := os.ReadDir(d.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(dirEntries))
+	for _, e := range dirEntries {
+		if e.IsDir() || e.Name()[0] == '.' {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// evict removes the least recently used entries until the total size fits in MaxSize.
+func (d *DiskCache) evict() error {
+	if d.MaxSize <= 0 {
+		return nil
+	}
+
+	infos, err := d.entries()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, info := range infos {
+		size += info.Size()
+	}
+	if size <= d.MaxSize {
+		return nil
+	}
+
+	slices.SortFunc(infos, func(a, b fs.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, info := range infos {
+		if size <= d.MaxSize {
+			break
+		}
+		err := os.Remove(filepath.Join(d.Dir, info.Name()))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		size -= info.Size()
+	}
+	return nil
+}
+
+// Clear removes all the entries.
+func (d *DiskCache) Clear() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	infos, err := d.entries()
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		err := os.Remove(filepath.Join(d.Dir, info.Name()))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DiskCache) Stats() (*DiskCacheStats, error) {
+	infos, err := d.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := DiskCacheStats{Dir: d.Dir, Entries: len(infos), Size: 0, MaxSize: d.MaxSize}
+	for _, info := range infos {
+		stats.Size += info.Size()
+	}
+	return &stats, nil
+}
@@ -1,31 +1,114 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 )
 
-func (c *Client) Download(path, output string) (n int64, err error) {
-	resp, err := c.NewRequest().Get(path)
+const partialSuffix = ".part"
+
+// PartialPath returns the path of the partial file used while downloading output.
+func PartialPath(output string) string {
+	return output + partialSuffix
+}
+
+// contentRangeStart returns the first byte position of a Content-Range header,
+// e.g. "bytes 100-199/200" -> 100.
+func contentRangeStart(contentRange string) (int64, bool) {
+	unit, rest, ok := strings.Cut(contentRange, " ")
+	if !ok || unit != "bytes" {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
 	if err != nil {
-		return 0, err
+		return 0, false
 	}
+	return n, true
+}
 
-	if resp.IsSuccess() {
-		w, err := os.Create(output)
+// Download downloads path to output. The content is written to a partial file
+// (output + ".part") first and renamed to output only when the download is completed.
+// If a partial file already exists, the download is resumed with a Range request
+// when the server supports it.
+func (c *Client) Download(path, output string) (n int64, err error) {
+	return c.DownloadContext(context.Background(), path, output)
+}
+
+func (c *Client) DownloadContext(ctx context.Context, path, output string) (n int64, err error) {
+	partial := PartialPath(output)
+
+	var offset int64
+	info, err := os.Stat(partial)
+	if err == nil {
+		offset = info.Size()
+	}
+
+	resp, err := c.downloadFrom(ctx, path, offset)
+	if err != nil && offset > 0 && resp != nil && resp.Response != nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// the partial file is stale, start over once
+		err = os.Remove(partial)
 		if err != nil {
 			return 0, err
 		}
-		defer func() {
-			closeErr := w.Close()
-			if closeErr != nil && err == nil {
-				err = closeErr
-			}
-		}()
+		offset = 0
+		resp, err = c.downloadFrom(ctx, path, offset)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		closeErr := resp.Body.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	// append to the partial file only if the server honored the range request
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	start, ok := contentRangeStart(resp.Header.Get("Content-Range"))
+	if offset > 0 && resp.StatusCode == http.StatusPartialContent && ok && start == offset {
+		flag = os.O_WRONLY | os.O_APPEND
+	} else {
+		offset = 0
+	}
+
+	w, err := os.OpenFile(partial, flag, 0o644)
+	if err != nil {
+		return 0, err
+	}
 
-		return io.Copy(w, resp.Body)
+	n, err = io.Copy(w, resp.Body)
+	closeErr := w.Close()
+	if closeErr != nil && err == nil {
+		err = closeErr
 	}
+	if err != nil {
+		// keep the partial file for resuming
+		return offset + n, err
+	}
+
+	err = os.Rename(partial, output)
+	if err != nil {
+		return offset + n, err
+	}
+
+	return offset + n, nil
+}
 
-	return 0, fmt.Errorf("unknown error downloading %q, HTTP response code: %d", resp.Request.RawRequest.URL, resp.StatusCode)
+// downloadFrom requests path from the byte offset.
+func (c *Client) downloadFrom(ctx context.Context, path string, offset int64) (*Response, error) {
+	req := c.NewRequest().SetContext(ctx).SetStream(true)
+	if offset > 0 {
+		req.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return req.Get(path)
 }
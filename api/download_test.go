@@ -0,0 +1,171 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownload(t *testing.T) {
+	defer gock.Off()
+
+	t.Run("writes to the output atomically", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver/").
+			Get("/test").
+			Reply(http.StatusOK).
+			BodyString("test content")
+
+		output := filepath.Join(t.TempDir(), "test.txt")
+
+		c := newTestClient()
+		n, err := c.Download("/test", output)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), n)
+
+		content, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "test content", string(content))
+		assert.NoFileExists(t, PartialPath(output))
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("resumes from the partial file", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver/").
+			Get("/test").
+			MatchHeader("Range", "bytes=5-").
+			Reply(http.StatusPartialContent).
+			SetHeader("Content-Range", "bytes 5-11/12").
+			BodyString("content")
+
+		output := filepath.Join(t.TempDir(), "test.txt")
+		err := os.WriteFile(PartialPath(output), []byte("test "), 0o644)
+		assert.NoError(t, err)
+
+		c := newTestClient()
+		n, err := c.Download("/test", output)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), n)
+
+		content, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "test content", string(content))
+		assert.NoFileExists(t, PartialPath(output))
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("starts over when the server ignores the range", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver/").
+			Get("/test").
+			MatchHeader("Range", "bytes=5-").
+			Reply(http.StatusOK).
+			BodyString("test content")
+
+		output := filepath.Join(t.TempDir(), "test.txt")
+		err := os.WriteFile(PartialPath(output), []byte("stale"), 0o644)
+		assert.NoError(t, err)
+
+		c := newTestClient()
+		_, err = c.Download("/test", output)
+		assert.NoError(t, err)
+
+		content, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "test content", string(content))
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("starts over once when the partial file is stale", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver/").
+			Get("/test").
+			MatchHeader("Range", "bytes=21-").
+			Reply(http.StatusRequestedRangeNotSatisfiable).
+			SetHeader("Content-Range", "bytes */12")
+		gock.New("http://testserver/").
+			Get("/test").
+			Reply(http.StatusOK).
+			BodyString("test content")
+
+		output := filepath.Join(t.TempDir(), "test.txt")
+		err := os.WriteFile(PartialPath(output), []byte("stale partial content"), 0o644)
+		assert.NoError(t, err)
+
+		c := newTestClient()
+		n, err := c.Download("/test", output)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), n)
+
+		content, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "test content", string(content))
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("fails when the restart is not satisfiable either", func(t *testing.T) {
+		// the mock which must not be requested is left pending
+		defer gock.Flush()
+
+		gock.New("http://testserver/").
+			Get("/test").
+			Times(2).
+			Reply(http.StatusRequestedRangeNotSatisfiable).
+			SetHeader("Content-Range", "bytes */0")
+		gock.New("http://testserver/").
+			Get("/test").
+			Reply(http.StatusOK).
+			BodyString("never requested")
+
+		output := filepath.Join(t.TempDir(), "test.txt")
+		err := os.WriteFile(PartialPath(output), []byte("stale"), 0o644)
+		assert.NoError(t, err)
+
+		c := newTestClient()
+		_, err = c.Download("/test", output)
+		assert.Error(t, err)
+		assert.NoFileExists(t, output)
+		// the request is not restarted more than once
+		assert.Len(t, gock.Pending(), 1)
+	})
+
+	t.Run("keeps the partial file on error", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver/").
+			Get("/test").
+			ReplyError(fmt.Errorf("network error"))
+
+		output := filepath.Join(t.TempDir(), "test.txt")
+		err := os.WriteFile(PartialPath(output), []byte("test "), 0o644)
+		assert.NoError(t, err)
+
+		c := newTestClient()
+		_, err = c.Download("/test", output)
+		assert.Error(t, err)
+		assert.NoFileExists(t, output)
+		assert.FileExists(t, PartialPath(output))
+	})
+}
+
+func TestContentRangeStart(t *testing.T) {
+	start, ok := contentRangeStart("bytes 100-199/200")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), start)
+
+	_, ok = contentRangeStart("bytes */200")
+	assert.False(t, ok)
+
+	_, ok = contentRangeStart("")
+	assert.False(t, ok)
+}
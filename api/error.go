@@ -1,12 +1,47 @@
 package api
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
 
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	// ErrQuotaExceeded is returned when the hourly or daily rate limit (quota) is exceeded.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrScanBlocked is returned when a scan is refused by urlscan.io (e.g. blocked domain).
+	ErrScanBlocked = errors.New("scan blocked")
+	// ErrDNSFailure is returned when a scan is refused because the domain could not be resolved.
+	ErrDNSFailure = errors.New("DNS failure")
+)
+
+// Messages of the 400 responses of a refused scan: https://urlscan.io/docs/api/#submission
+// The message of a blocked scan is followed by the reason (e.g. "Scan prevented - Blocklisted domain").
+const (
+	dnsFailureMessage        = "DNS Error - Could not resolve domain"
+	scanBlockedMessagePrefix = "Scan prevented"
+)
+
+// quotaRateLimitWindows are the rate limit windows (X-Rate-Limit-Window) of a quota, which is not
+// reset soon unlike the rate limit of a shorter window.
+var quotaRateLimitWindows = []string{"hour", "day"}
+
+// JSONError is the error of a non-2xx response. Response.Error returns it as a value
+// (match it by errors.As with a JSONError), but the sentinel errors (e.g. ErrNotFound)
+// are matched by errors.Is with both a JSONError and a *JSONError.
 type JSONError struct {
 	Status      int             `json:"status,omitempty"`
 	Message     string          `json:"message"`
 	Description string          `json:"description,omitempty"`
 	Raw         json.RawMessage `json:"-"`
+	// Header is the header of the response.
+	Header http.Header `json:"-"`
 }
 
 func (r *JSONError) UnmarshalJSON(data []byte) error {
@@ -25,3 +60,47 @@ func (r *JSONError) UnmarshalJSON(data []byte) error {
 func (e JSONError) Error() string {
 	return e.Message
 }
+
+// isQuotaExceeded reports whether the rate limit of a quota window is exceeded.
+func (e JSONError) isQuotaExceeded() bool {
+	if e.Status != http.StatusTooManyRequests {
+		return false
+	}
+	// rate limit headers: https://urlscan.io/docs/api/#ratelimit
+	return slices.Contains(quotaRateLimitWindows, e.Header.Get("X-Rate-Limit-Window"))
+}
+
+// Is makes JSONError comparable with the sentinel errors by errors.Is.
+func (e JSONError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Status == http.StatusForbidden
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	case ErrQuotaExceeded:
+		return e.isQuotaExceeded()
+	case ErrDNSFailure:
+		return e.Status == http.StatusBadRequest && e.Message == dnsFailureMessage
+	case ErrScanBlocked:
+		return e.Status == http.StatusBadRequest && strings.HasPrefix(e.Message, scanBlockedMessagePrefix)
+	default:
+		return false
+	}
+}
+
+// asJSONError finds the first JSONError (or *JSONError) in the tree of err.
+func asJSONError(err error) (JSONError, bool) {
+	jsonErr, ok := errors.AsType[JSONError](err)
+	if ok {
+		return jsonErr, true
+	}
+	ptr, ok := errors.AsType[*JSONError](err)
+	if ok && ptr != nil {
+		return *ptr, true
+	}
+	return jsonErr, false
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *JSONError
+		target error
+		want   bool
+	}{
+		{"unauthorized", &JSONError{Status: 401, Message: "Unauthorized", Description: "", Raw: nil, Header: nil}, ErrUnauthorized, true},
+		{"forbidden", &JSONError{Status: 403, Message: "Forbidden", Description: "", Raw: nil, Header: nil}, ErrForbidden, true},
+		{"not found", &JSONError{Status: 404, Message: "Scan is not finished yet", Description: "", Raw: nil, Header: nil}, ErrNotFound, true},
+		{"rate limited", &JSONError{Status: 429, Message: "Rate limit exceeded", Description: "", Raw: nil, Header: nil}, ErrRateLimited, true},
+		{"rate limited is not quota", &JSONError{Status: 429, Message: "Rate limit exceeded", Description: "", Raw: nil, Header: nil}, ErrQuotaExceeded, false},
+		{"quota exceeded", &JSONError{Status: 429, Message: "Rate limit exceeded", Description: "", Raw: nil, Header: http.Header{"X-Rate-Limit-Window": {"day"}}}, ErrQuotaExceeded, true},
+		{"quota is rate limited", &JSONError{Status: 429, Message: "Rate limit exceeded", Description: "", Raw: nil, Header: http.Header{"X-Rate-Limit-Window": {"day"}}}, ErrRateLimited, true},
+		{"minute window is not quota", &JSONError{Status: 429, Message: "Daily quota exceeded", Description: "", Raw: nil, Header: http.Header{"X-Rate-Limit-Window": {"minute"}}}, ErrQuotaExceeded, false},
+		{"dns failure", &JSONError{Status: 400, Message: "DNS Error - Could not resolve domain", Description: "", Raw: nil, Header: nil}, ErrDNSFailure, true},
+		{"scan blocked", &JSONError{Status: 400, Message: "Scan prevented - Blocklisted domain", Description: "The domain is blocked from scanning", Raw: nil, Header: nil}, ErrScanBlocked, true},
+		{"scan blocked with another status", &JSONError{Status: 403, Message: "Scan prevented - Blocklisted domain", Description: "", Raw: nil, Header: nil}, ErrScanBlocked, false},
+		{"bad request", &JSONError{Status: 400, Message: "Bad Request", Description: "", Raw: nil, Header: nil}, ErrScanBlocked, false},
+		{"blocked in the description", &JSONError{Status: 400, Message: "Missing URL properties", Description: "The URL is blocked", Raw: nil, Header: nil}, ErrScanBlocked, false},
+		{"status mismatch", &JSONError{Status: 500, Message: "Not Found", Description: "", Raw: nil, Header: nil}, ErrNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errors.Is(tt.err, tt.target))
+		})
+	}
+}
+
+func TestResponseErrorIsTyped(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").Get("/json").Reply(404).JSON(map[string]any{"status": 404, "message": "Not Found"})
+	gock.New("http://testserver/").Get("/html").Reply(401).BodyString("<html>Unauthorized</html>")
+
+	c := newTestClient()
+
+	_, err := c.NewRequest().Get("/json")
+	assert.ErrorIs(t, err, ErrNotFound)
+	jsonErr, ok := errors.AsType[JSONError](err)
+	assert.True(t, ok)
+	assert.Equal(t, 404, jsonErr.Status)
+
+	_, err = c.NewRequest().Get("/html")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, "Unauthorized", err.Error())
+
+	assert.Equal(t, gock.IsDone(), true)
+}
+
+func TestResponseErrorAPIBodies(t *testing.T) {
+	defer gock.Off()
+
+	// the error bodies of https://urlscan.io/docs/api/
+	gock.New("http://testserver/").Post("/api/v1/scan/").Times(1).Reply(400).JSON(map[string]any{
+		"message":     "DNS Error - Could not resolve domain",
+		"description": "The domain .google.com could not be resolved to a valid IPv4/IPv6 address. We won't try to load it in the browser.",
+		"status":      400,
+	})
+	gock.New("http://testserver/").Post("/api/v1/scan/").Times(1).Reply(400).JSON(map[string]any{
+		"message":     "Scan prevented - Domain is on our blacklist",
+		"description": "The submitted domain is on our blacklist. For your own safety we did not perform this scan...",
+		"status":      400,
+	})
+	gock.New("http://testserver/").Post("/api/v1/scan/").Times(1).Reply(400).JSON(map[string]any{
+		"message":     "Missing URL properties",
+		"description": "The URL supplied was not OK, please specify it including the protocol, host and path (e.g. http://example.com/bar)",
+		"status":      400,
+	})
+	for _, window := range []string{"minute", "day"} {
+		gock.New("http://testserver/").Post("/api/v1/scan/").Times(1).Reply(429).
+			SetHeaders(map[string]string{
+				"X-Rate-Limit-Scope":       "team",
+				"X-Rate-Limit-Action":      "public",
+				"X-Rate-Limit-Window":      window,
+				"X-Rate-Limit-Limit":       "60",
+				"X-Rate-Limit-Remaining":   "0",
+				"X-Rate-Limit-Reset-After": "60",
+			}).
+			JSON(map[string]any{
+				"message":     "Rate limit exceeded",
+				"description": "You have exceeded your rate limit for this action. Please wait before sending more requests.",
+				"status":      429,
+			})
+	}
+
+	c := newTestClient().SetRetryPolicy(&RetryPolicy{MaxAttempts: 1}).SetRateLimiter(nil) //nolint:exhaustruct
+	submit := func() error {
+		_, err := c.NewRequest().SetBodyJSONBytes([]byte(`{"url":"https://example.com"}`)).Post("/api/v1/scan/")
+		return err
+	}
+
+	err := submit()
+	assert.ErrorIs(t, err, ErrDNSFailure)
+	assert.NotErrorIs(t, err, ErrScanBlocked)
+
+	err = submit()
+	assert.ErrorIs(t, err, ErrScanBlocked)
+	assert.NotErrorIs(t, err, ErrDNSFailure)
+
+	// a bad request for another reason
+	err = submit()
+	assert.NotErrorIs(t, err, ErrScanBlocked)
+	assert.NotErrorIs(t, err, ErrDNSFailure)
+
+	err = submit()
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.NotErrorIs(t, err, ErrQuotaExceeded)
+
+	err = submit()
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// errors.As with a JSONError value works as before
+	var jsonErr JSONError
+	assert.True(t, errors.As(err, &jsonErr))
+	assert.Equal(t, "day", jsonErr.Header.Get("X-Rate-Limit-Window"))
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// exhaustiveSince is the lower bound of the date windows of an exhaustive search.
+// urlscan.io has no scans older than this.
+var exhaustiveSince = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// minDateWindow is the smallest date window an exhaustive search splits a query into.
+const minDateWindow = time.Second
+
+// IteratorExhaustive makes the iterator get past the MaxTotal cap of a query by splitting
+// it into date windows which have fewer results than the cap. The windows are found by
+// count-only requests as the iteration proceeds, and iterated newest first as one
+// de-duplicated stream.
+func IteratorExhaustive(exhaustive bool) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.exhaustive = exhaustive
+		return nil
+	}
+}
+
+// IteratorExhaustivePlan makes the iterator exhaustive (see IteratorExhaustive) and iterate
+// over the date windows of the plan instead of finding them again.
+func IteratorExhaustivePlan(plan *ExhaustivePlan) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.exhaustive = true
+		cfg.exhaustivePlan = plan
+		return nil
+	}
+}
+
+// ExhaustivePlan is the date windows of an exhaustive search found ahead of the iteration.
+type ExhaustivePlan struct {
+	// Total is the number of results in the windows.
+	Total int
+	// Requests is the number of count-only requests made to find the windows.
+	Requests int
+	windows  []dateWindow
+}
+
+type dateWindow struct {
+	from  time.Time
+	to    time.Time
+	total int
+}
+
+// dateWindowQuery restricts q to the dates in [from, to).
+func dateWindowQuery(q string, from, to time.Time) string {
+	const layout = "2006-01-02T15:04:05Z"
+	window := fmt.Sprintf(`date:["%s" TO "%s"}`, from.UTC().Format(layout), to.UTC().Format(layout))
+	if q == "" {
+		return window
+	}
+	return fmt.Sprintf("(%s) AND %s", q, window)
+}
+
+// newWindowIterator returns an iterator over all results of q in the window.
+func (it *PageIterator[T]) newWindowIterator(q string, size int) (*PageIterator[T], error) {
+	return newIterator(it.client, it.path, it.paginator,
+		IteratorQuery(q),
+		IteratorSize(size),
+		IteratorAll(true),
+		IteratorDatasource(it.datasource),
+		IteratorCollapse(it.collapse),
+		IteratorContext(it.ctx),
+		IteratorPrefetch(it.prefetch),
+	)
+}
+
+func (it *PageIterator[T]) countQuery(q string) (int, error) {
+	counter, err := it.newWindowIterator(q, 0)
+	if err != nil {
+		return 0, err
+	}
+	for _, err := range counter.Iterate() {
+		if err != nil {
+			return 0, err
+		}
+	}
+	return counter.Total, nil
+}
+
+// dateWindows splits the query into date windows (newest first) under the cap as they are
+// iterated. probes is incremented by each count-only request.
+func (it *PageIterator[T]) dateWindows(probes *int) iter.Seq2[dateWindow, error] {
+	return func(yield func(dateWindow, error) bool) {
+		until := it.now().UTC().Truncate(time.Second).Add(time.Second)
+		stack := []dateWindow{{from: exhaustiveSince, to: until, total: -1}}
+
+		for len(stack) > 0 {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			*probes++
+			total, err := it.countQuery(dateWindowQuery(it.q, w.from, w.to))
+			if err != nil {
+				yield(dateWindow{}, err)
+				return
+			}
+			if total == 0 {
+				continue
+			}
+
+			if total >= it.maxTotal {
+				if w.to.Sub(w.from) > minDateWindow {
+					mid := w.from.Add(w.to.Sub(w.from) / 2).Truncate(time.Second)
+					if mid.After(w.from) {
+						// the newer half is popped first
+						stack = append(stack, dateWindow{from: w.from, to: mid, total: -1}, dateWindow{from: mid, to: w.to, total: -1})
+						continue
+					}
+				}
+				it.client.Logger().Warn("A date window has more results than the cap, some results are not retrievable",
+					"from", w.from.Format(time.RFC3339), "to", w.to.Format(time.RFC3339), "total", total)
+			}
+			if !yield(dateWindow{from: w.from, to: w.to, total: total}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// PlanExhaustive finds the date windows of an exhaustive search ahead of the iteration
+// (e.g. to check the number of results and requests against the quota). Pass the plan to
+// IteratorExhaustivePlan to iterate over the windows.
+func (it *PageIterator[T]) PlanExhaustive() (*ExhaustivePlan, error) {
+	plan := &ExhaustivePlan{Total: 0, Requests: 0, windows: nil}
+	for w, err := range it.dateWindows(&plan.Requests) {
+		if err != nil {
+			return nil, err
+		}
+		plan.windows = append(plan.windows, w)
+		plan.Total += w.total
+	}
+	return plan, nil
+}
+
+// windows returns the date windows of the plan, or finds them as they are iterated.
+func (it *PageIterator[T]) windows() iter.Seq2[dateWindow, error] {
+	if it.exhaustivePlan == nil {
+		var probes int
+		return it.dateWindows(&probes)
+	}
+	return func(yield func(dateWindow, error) bool) {
+		for _, w := range it.exhaustivePlan.windows {
+			if !yield(w, nil) {
+				return
+			}
+		}
+	}
+}
+
+// iterateExhaustive iterates over the results of the date windows. Without a plan, Total is
+// the number of results in the windows found so far until the last window is found.
+func (it *PageIterator[T]) iterateExhaustive() iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if it.exhaustivePlan != nil {
+			it.Total = it.exhaustivePlan.Total
+		} else {
+			it.Total = 0
+		}
+
+		keyer, _ := it.paginator.(itemKeyer[T])
+		// results on a window boundary may be returned twice, so the keys of the last page
+		// of the previous window are checked against the first page of the next one
+		var boundary map[string]struct{}
+		for w, err := range it.windows() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if it.exhaustivePlan == nil {
+				it.Total += w.total
+			}
+
+			windowIt, err := it.newWindowIterator(dateWindowQuery(it.q, w.from, w.to), it.size)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			pageSize := max(it.size, 1)
+			// tail is a ring of the keys of the last page of the window
+			tail := make([]string, 0, pageSize)
+			keyed := 0
+			n := 0
+			for result, err := range windowIt.Iterate() {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+
+				id := itemKey(keyer, result)
+				if id != "" {
+					if n < pageSize {
+						_, ok := boundary[id]
+						if ok {
+							continue
+						}
+					}
+					if len(tail) < pageSize {
+						tail = append(tail, id)
+					} else {
+						tail[keyed%pageSize] = id
+					}
+					keyed++
+				}
+				n++
+
+				if !yield(result, nil) {
+					return
+				}
+
+				it.count++
+				if !it.all && it.count >= it.limit {
+					it.HasMore = it.exhaustivePlan == nil || it.count < it.Total
+					return
+				}
+			}
+
+			boundary = make(map[string]struct{}, len(tail))
+			for _, id := range tail {
+				boundary[id] = struct{}{}
+			}
+		}
+		it.HasMore = false
+	}
+}
+
+// itemKey returns the key of an item or an empty string if the items have no keys.
+func itemKey[T any](keyer itemKeyer[T], item *T) string {
+	if keyer == nil {
+		return ""
+	}
+	return keyer.Key(item)
+}
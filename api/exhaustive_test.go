@@ -0,0 +1,235 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testDateWindowPattern = regexp.MustCompile(`date:\["([^"]+)" TO "([^"]+)"\}`)
+
+type testDoc struct {
+	id   string
+	date time.Time
+}
+
+// testSearchServer serves docs (newest first) and caps the total like urlscan.io.
+type testSearchServer struct {
+	docs     []testDoc
+	maxTotal int
+	// overlap extends the end of a date window to return the results on the boundary twice
+	overlap time.Duration
+	// probes is the number of count-only requests
+	probes atomic.Int32
+}
+
+func newTestSearchServer(t *testing.T, docs []testDoc, maxTotal int) *httptest.Server {
+	return (&testSearchServer{docs: docs, maxTotal: maxTotal}).start(t) //nolint:exhaustruct
+}
+
+func (ts *testSearchServer) start(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		from, to := time.Time{}, time.Now().Add(time.Hour)
+		m := testDateWindowPattern.FindStringSubmatch(query.Get("q"))
+		if m != nil {
+			var err error
+			from, err = time.Parse(time.RFC3339, m[1])
+			assert.NoError(t, err)
+			to, err = time.Parse(time.RFC3339, m[2])
+			assert.NoError(t, err)
+		}
+		to = to.Add(ts.overlap)
+
+		var matched []testDoc
+		for _, doc := range ts.docs {
+			if !doc.date.Before(from) && doc.date.Before(to) {
+				matched = append(matched, doc)
+			}
+		}
+		matched = matched[:min(len(matched), ts.maxTotal)]
+
+		start := 0
+		searchAfter := query.Get("search_after")
+		for i, doc := range matched {
+			if fmt.Sprintf("%d,%s", doc.date.UnixMilli(), doc.id) == searchAfter {
+				start = i + 1
+			}
+		}
+		size, _ := strconv.Atoi(query.Get("size"))
+		if size == 0 {
+			ts.probes.Add(1)
+		}
+		end := min(start+size, len(matched))
+
+		results := make([]map[string]any, 0)
+		for _, doc := range matched[start:end] {
+			results = append(results, map[string]any{"_id": doc.id, "sort": []any{doc.date.UnixMilli(), doc.id}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": results, "total": len(matched), "has_more": end < len(matched)})
+	}))
+}
+
+func TestSearchExhaustive(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := make([]testDoc, 0, 35)
+	for i := range 35 {
+		docs = append(docs, testDoc{id: fmt.Sprintf("doc-%02d", i), date: now.Add(-time.Duration(i) * time.Hour)})
+	}
+	s := newTestSearchServer(t, docs, 10)
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	c := NewClient("dummy")
+	c.SetBaseURL(u)
+
+	it, err := c.Search("page.domain:example.com", IteratorSize(4), IteratorAll(true), IteratorExhaustive(true))
+	assert.NoError(t, err)
+	it.maxTotal = 10
+	it.now = func() time.Time { return now }
+
+	var ids []string
+	for result, err := range it.Iterate() {
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(result.Sort[1]))
+	}
+
+	want := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		want = append(want, doc.id)
+	}
+	assert.Equal(t, want, ids)
+	assert.Equal(t, 35, it.Total)
+	assert.False(t, it.HasMore)
+}
+
+func TestSearchExhaustiveLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := make([]testDoc, 0, 20)
+	for i := range 20 {
+		docs = append(docs, testDoc{id: fmt.Sprintf("doc-%02d", i), date: now.Add(-time.Duration(i) * time.Minute)})
+	}
+	s := newTestSearchServer(t, docs, 10)
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	c := NewClient("dummy")
+	c.SetBaseURL(u)
+
+	it, err := c.Search("", IteratorSize(5), IteratorLimit(12), IteratorExhaustive(true))
+	assert.NoError(t, err)
+	it.maxTotal = 10
+	it.now = func() time.Time { return now }
+
+	count := 0
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 12, count)
+	assert.Equal(t, 20, it.Total)
+	assert.True(t, it.HasMore)
+}
+
+func TestDateWindowQuery(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	assert.Equal(t, `(page.domain:example.com) AND date:["2026-01-01T00:00:00Z" TO "2026-01-01T01:00:00Z"}`,
+		dateWindowQuery("page.domain:example.com", from, to))
+	assert.Equal(t, `date:["2026-01-01T00:00:00Z" TO "2026-01-01T01:00:00Z"}`, dateWindowQuery("", from, to))
+}
+
+func newTestDocs(n int, now time.Time, interval time.Duration) []testDoc {
+	docs := make([]testDoc, 0, n)
+	for i := range n {
+		docs = append(docs, testDoc{id: fmt.Sprintf("doc-%02d", i), date: now.Add(-time.Duration(i) * interval)})
+	}
+	return docs
+}
+
+func TestSearchExhaustiveStreams(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := &testSearchServer{docs: newTestDocs(35, now, time.Hour), maxTotal: 10} //nolint:exhaustruct
+	s := ts.start(t)
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	c := NewClient("dummy").SetBaseURL(u)
+
+	newSearch := func(opts ...IteratorOption) *SearchIterator {
+		opts = append(opts, IteratorSize(4), IteratorAll(true), IteratorExhaustive(true))
+		it, err := c.Search("page.domain:example.com", opts...)
+		assert.NoError(t, err)
+		it.maxTotal = 10
+		it.now = func() time.Time { return now }
+		return it
+	}
+
+	plan, err := newSearch().PlanExhaustive()
+	assert.NoError(t, err)
+	assert.Equal(t, 35, plan.Total)
+	assert.Equal(t, int(ts.probes.Load()), plan.Requests)
+
+	// the first result comes before all the windows are found
+	ts.probes.Store(0)
+	for _, err := range newSearch().Iterate() {
+		assert.NoError(t, err)
+		break
+	}
+	assert.Less(t, int(ts.probes.Load()), plan.Requests)
+
+	// the windows of the plan are not found again
+	ts.probes.Store(0)
+	it := newSearch(IteratorExhaustivePlan(plan))
+	count := 0
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 35, count)
+	assert.Equal(t, 35, it.Total)
+	assert.Equal(t, int32(0), ts.probes.Load())
+}
+
+func TestSearchExhaustiveBoundaryDuplicates(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := newTestDocs(35, now, time.Hour)
+	ts := &testSearchServer{docs: docs, maxTotal: 10, overlap: time.Hour} //nolint:exhaustruct
+	s := ts.start(t)
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	c := NewClient("dummy").SetBaseURL(u)
+
+	it, err := c.Search("", IteratorSize(4), IteratorAll(true), IteratorExhaustive(true))
+	assert.NoError(t, err)
+	it.maxTotal = 10
+	it.now = func() time.Time { return now }
+
+	var ids []string
+	for result, err := range it.Iterate() {
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(result.Sort[1]))
+	}
+
+	// the first result of each window is the last one of the newer window
+	want := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		want = append(want, doc.id)
+	}
+	assert.Equal(t, want, ids)
+}
@@ -36,3 +36,61 @@ func TestHostname(t *testing.T) {
 	}
 	assert.Equal(t, 1, count)
 }
+
+func TestHostnameCheckpointAndResume(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/hostname/example.com").
+		MatchParam("pageState", "page2").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":["c"], "pageState": "page3"}`)
+
+	c := newTestClient()
+	var checkpoints []Checkpoint
+	it, err := c.IterateHostname("example.com",
+		HostnameIteratorAll(true),
+		HostnameIteratorSize(2),
+		HostnameIteratorResume(Checkpoint{Cursor: "page2", Count: 2, Total: 0, HasMore: true}),
+		HostnameIteratorCheckpoint(func(cp Checkpoint) error {
+			checkpoints = append(checkpoints, cp)
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+
+	count := 0
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []Checkpoint{{Cursor: "page3", Count: 3, Total: 0, HasMore: false}}, checkpoints)
+	assert.True(t, gock.IsDone())
+}
+
+func TestHostnameLimitZero(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/hostname/example.com").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":["a"], "pageState": "page2"}`)
+
+	c := newTestClient()
+	for _, prefetch := range []int{0, 1} {
+		it, err := c.IterateHostname("example.com", HostnameIteratorLimit(0), IteratorPrefetch(prefetch))
+		assert.NoError(t, err)
+
+		count := 0
+		for _, err := range it.Iterate() {
+			assert.NoError(t, err)
+			count++
+		}
+		assert.Equal(t, 0, count)
+	}
+	// no request is made
+	assert.Len(t, gock.Pending(), 1)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -165,6 +166,10 @@ func newIncidentOptions(opts ...IncidentOption) (*IncidentOptions, error) {
 }
 
 func (c *Client) CreateIncident(opts ...IncidentOption) (*Response, error) {
+	return c.CreateIncidentContext(context.Background(), opts...)
+}
+
+func (c *Client) CreateIncidentContext(ctx context.Context, opts ...IncidentOption) (*Response, error) {
 	incidentOpts, err := newIncidentOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -174,10 +179,14 @@ func (c *Client) CreateIncident(opts ...IncidentOption) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Post(PrefixedPath("/user/incidents/"))
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Post(PrefixedPath("/user/incidents/"))
 }
 
 func (c *Client) UpdateIncident(id string, opts ...IncidentOption) (*Response, error) {
+	return c.UpdateIncidentContext(context.Background(), id, opts...)
+}
+
+func (c *Client) UpdateIncidentContext(ctx context.Context, id string, opts ...IncidentOption) (*Response, error) {
 	incidentOpts, err := newIncidentOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -187,5 +196,5 @@ func (c *Client) UpdateIncident(id string, opts ...IncidentOption) (*Response, e
 	if err != nil {
 		return nil, err
 	}
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Put(PrefixedPath(fmt.Sprintf("/user/incidents/%s/", id)))
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Put(PrefixedPath(fmt.Sprintf("/user/incidents/%s/", id)))
 }
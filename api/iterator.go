@@ -1,10 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"iter"
-	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const MaxTotal = 10_000
@@ -50,105 +54,219 @@ func (r *SearchResults) UnmarshalJSON(data []byte) error {
 	return err
 }
 
-type IteratorOption func(*Iterator) error
+// iteratorConfig is the configuration shared by all iterators. Options which don't
+// apply to an endpoint (e.g. the query of a list endpoint) are ignored.
+type iteratorConfig struct {
+	limit      int
+	all        bool
+	size       int
+	q          string
+	cursor     string
+	datasource string
+	collapse   string
+	ctx        context.Context
+	prefetch   int
+	checkpoint CheckpointFunc
+	exhaustive bool
+	// exhaustivePlan is the date windows of an exhaustive search found ahead
+	exhaustivePlan *ExhaustivePlan
+	// iteratorFuncs are the options of the former form applied to the iterator (see IteratorFunc)
+	iteratorFuncs []func(it any) error
+	count         int
+	total         int
+}
+
+type IteratorOption func(*iteratorConfig) error
 
-func IteratorSearchAfter(searchAfter string) IteratorOption {
-	return func(it *Iterator) error {
-		it.searchAfter = searchAfter
+// IteratorCursor starts the iteration from the cursor of a previous iteration
+// (search_after of the search API, pageState of the hostname API).
+func IteratorCursor(cursor string) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.cursor = cursor
 		return nil
 	}
 }
 
+func IteratorSearchAfter(searchAfter string) IteratorOption {
+	return IteratorCursor(searchAfter)
+}
+
+// size is the number of results returned by the iterator in each batch.
 func IteratorSize(n int) IteratorOption {
-	return func(it *Iterator) error {
-		it.size = n
+	return func(cfg *iteratorConfig) error {
+		cfg.size = n
 		return nil
 	}
 }
 
+// limit is the maximum number of results that will be returned by the iterator.
 func IteratorLimit(n int) IteratorOption {
-	return func(it *Iterator) error {
-		it.limit = n
+	return func(cfg *iteratorConfig) error {
+		cfg.limit = n
 		return nil
 	}
 }
 
 func IteratorAll(all bool) IteratorOption {
-	return func(it *Iterator) error {
-		it.all = all
+	return func(cfg *iteratorConfig) error {
+		cfg.all = all
 		return nil
 	}
 }
 
 func IteratorQuery(q string) IteratorOption {
-	return func(it *Iterator) error {
-		it.q = q
+	return func(cfg *iteratorConfig) error {
+		cfg.q = q
 		return nil
 	}
 }
 
 func IteratorDatasource(datasource string) IteratorOption {
-	return func(it *Iterator) error {
-		it.datasource = datasource
+	return func(cfg *iteratorConfig) error {
+		cfg.datasource = datasource
+		return nil
+	}
+}
+
+// IteratorContext sets the context used for the page requests made by the iterator.
+func IteratorContext(ctx context.Context) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.ctx = ctx
+		return nil
+	}
+}
+
+// IteratorPrefetch makes the iterator request up to depth pages ahead in the background
+// while the current page is consumed. Zero (default) disables prefetching.
+func IteratorPrefetch(depth int) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		if depth < 0 {
+			return fmt.Errorf("prefetch depth must be zero or positive: %d", depth)
+		}
+		cfg.prefetch = depth
+		return nil
+	}
+}
+
+// IteratorCheckpoint sets the function called with the iterator state after every page is consumed.
+func IteratorCheckpoint(fn CheckpointFunc) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.checkpoint = fn
+		return nil
+	}
+}
+
+// IteratorResume continues the iteration from a checkpoint. The count of the checkpoint
+// counts towards the limit.
+func IteratorResume(cp Checkpoint) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.cursor = cp.Cursor
+		cfg.count = cp.Count
+		cfg.total = cp.Total
 		return nil
 	}
 }
 
 func IteratorCollapse(collapse string) IteratorOption {
-	return func(it *Iterator) error {
-		it.collapse = collapse
+	return func(cfg *iteratorConfig) error {
+		cfg.collapse = collapse
+		return nil
+	}
+}
+
+// IteratorFunc adapts an option of the former form func(*Iterator) error (or
+// func(*HostnameIterator) error), which is applied to the iterator after the other options.
+//
+// Deprecated: Use the IteratorOption functions.
+func IteratorFunc[T any](fn func(*PageIterator[T]) error) IteratorOption {
+	return func(cfg *iteratorConfig) error {
+		cfg.iteratorFuncs = append(cfg.iteratorFuncs, func(it any) error {
+			pit, ok := it.(*PageIterator[T])
+			if !ok {
+				return fmt.Errorf("iterator option of %T does not apply to %T", pit, it)
+			}
+			return fn(pit)
+		})
 		return nil
 	}
 }
 
-type Iterator struct {
-	client      *Client
-	path        string
-	request     *Request
-	limit       int
-	all         bool
-	size        int
-	q           string
-	searchAfter string
-	datasource  string
-	collapse    string
-	count       int
-	HasMore     bool
-	Total       int
+// PageIterator iterates over the items of a paginated endpoint. The pagination (the cursor
+// and the decoding of a page) is delegated to a Paginator while the limit, all and size
+// semantics, prefetching and checkpoints are shared by all endpoints.
+type PageIterator[T any] struct {
+	iteratorConfig
+	client    *Client
+	path      string
+	request   *Request
+	paginator Paginator[T]
+	maxTotal  int
+	now       func() time.Time
+	HasMore   bool
+	Total     int
+	// Raw is the body of the first page (e.g. the response of an endpoint returning all items at once).
+	Raw json.RawMessage
 }
 
-func newIterator(c *Client, path string, options ...IteratorOption) (*Iterator, error) {
+// SearchIterator iterates over the results of the search and structure search APIs.
+type SearchIterator = PageIterator[SearchResult]
+
+// Iterator is the former name of SearchIterator.
+//
+// Deprecated: Use SearchIterator.
+type Iterator = SearchIterator
+
+func newIterator[T any](c *Client, path string, paginator Paginator[T], options ...IteratorOption) (*PageIterator[T], error) {
 	request := c.NewRequest().SetPath(path)
 
-	it := &Iterator{
-		client:  c,
-		path:    path,
-		request: request,
-		// default values
-		all:         false,
-		count:       0,
-		datasource:  "",
-		collapse:    "",
-		HasMore:     true,
-		limit:       0,
-		q:           "",
-		searchAfter: "",
-		size:        0,
-		Total:       0,
+	it := &PageIterator[T]{
+		iteratorConfig: iteratorConfig{
+			// default values
+			all:            false,
+			checkpoint:     nil,
+			collapse:       "",
+			count:          0,
+			ctx:            nil,
+			cursor:         "",
+			datasource:     "",
+			exhaustive:     false,
+			exhaustivePlan: nil,
+			iteratorFuncs:  nil,
+			limit:          0,
+			prefetch:       0,
+			q:              "",
+			size:           0,
+			total:          0,
+		},
+		client:    c,
+		path:      path,
+		request:   request,
+		paginator: paginator,
+		maxTotal:  MaxTotal,
+		now:       time.Now,
+		HasMore:   true,
+		Total:     0,
+		Raw:       nil,
 	}
 
 	for _, opt := range options {
-		if err := opt(it); err != nil {
+		if err := opt(&it.iteratorConfig); err != nil {
+			return nil, err
+		}
+	}
+	it.Total = it.total
+	for _, fn := range it.iteratorFuncs {
+		if err := fn(it); err != nil {
 			return nil, err
 		}
 	}
 
-	if it.q != "" {
-		it.request.SetQueryParam("q", it.q)
+	if it.ctx != nil {
+		it.request.SetContext(it.ctx)
 	}
 
-	if it.searchAfter != "" {
-		it.request.SetQueryParam("search_after", it.searchAfter)
+	if it.q != "" {
+		it.request.SetQueryParam("q", it.q)
 	}
 
 	if it.datasource != "" {
@@ -159,96 +277,222 @@ func newIterator(c *Client, path string, options ...IteratorOption) (*Iterator,
 		it.request.SetQueryParam("collapse", it.collapse)
 	}
 
-	if it.size >= 0 {
-		it.request.SetQueryParam("size", strconv.Itoa(it.size))
-	}
-
 	return it, nil
 }
 
-func (it *Iterator) getMoreResults() (results []*SearchResult, err error) {
-	resp, err := it.request.Get(it.path)
-	if err != nil {
-		return nil, err
+// Cursor returns the cursor of the next page. Pass it to IteratorCursor to continue the iteration later.
+func (it *PageIterator[T]) Cursor() string {
+	return it.cursor
+}
+
+// PageState returns the pageState of the next page of the hostname API.
+//
+// Deprecated: Use Cursor.
+func (it *PageIterator[T]) PageState() string {
+	return it.cursor
+}
+
+// requestFirstPage reports whether the first page is requested even if no item is wanted
+// so Total and HasMore are populated.
+func (it *PageIterator[T]) requestFirstPage() bool {
+	_, ok := it.paginator.(totalless)
+	return !ok
+}
+
+// pageResult is a fetched page or the error of fetching it.
+type pageResult[T any] struct {
+	page *Page[T]
+	err  error
+}
+
+// fetchPage requests the page after cursor. fetched is the number of items fetched before the page.
+func (it *PageIterator[T]) fetchPage(ctx context.Context, cursor string, fetched int) (page *Page[T], err error) {
+	pageReq := PageRequest{Cursor: cursor, Size: it.size, Fetched: fetched}
+	it.paginator.Prepare(it.request, pageReq)
+
+	if it.client.telemetry != nil {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var finish func(error, ...attribute.KeyValue)
+		ctx, finish = it.client.telemetry.startSpan(ctx, "urlscan.iterator.page",
+			attribute.String("url.template", endpointTemplate(it.path)),
+			attribute.Int("urlscan.iterator.fetched", fetched),
+			attribute.Bool("urlscan.iterator.first_page", cursor == ""))
+		defer func() {
+			var items int
+			if page != nil {
+				items = len(page.Items)
+			}
+			finish(err, attribute.Int("urlscan.iterator.items", items))
+		}()
 	}
 
-	var r SearchResults
-	err = resp.Unmarshal(&r)
+	if ctx != nil {
+		it.request.SetContext(ctx)
+	}
+	resp, err := it.request.Get(it.path)
 	if err != nil {
 		return nil, err
 	}
+	return it.paginator.Decode(resp, pageReq)
+}
 
+// apply updates the iterator state with a page.
+func (it *PageIterator[T]) apply(page *Page[T]) {
 	// set total only once (= when the first request is made)
 	if it.Total == 0 {
-		it.Total = r.Total
+		it.Total = page.Total
+	}
+	if it.Raw == nil {
+		it.Raw = page.Raw
 	}
+	it.cursor = page.Cursor
+	it.HasMore = page.HasMore
+}
 
-	for _, result := range r.Results {
-		results = append(results, &result)
+// saveCheckpoint calls the checkpoint function with the current state.
+func (it *PageIterator[T]) saveCheckpoint() error {
+	if it.checkpoint == nil {
+		return nil
 	}
-	// set searchAfter for the next request
-	if len(r.Results) > 0 {
-		last := r.Results[len(r.Results)-1]
+	return it.checkpoint(Checkpoint{
+		Cursor:  it.cursor,
+		Count:   it.count,
+		Total:   it.Total,
+		HasMore: it.HasMore,
+	})
+}
 
-		if len(last.Sort) >= 2 {
-			timestamp, ok := last.Sort[0].(float64)
-			if !ok {
-				return nil, fmt.Errorf("invalid result sort format")
-			}
+func (it *PageIterator[T]) getMoreResults() ([]*T, error) {
+	page, err := it.fetchPage(it.ctx, it.cursor, it.count)
+	if err != nil {
+		return nil, err
+	}
+	it.apply(page)
+	return page.Items, nil
+}
 
-			uuid, ok := last.Sort[1].(string)
-			if !ok {
-				return nil, fmt.Errorf("invalid result sort format")
-			}
-			it.searchAfter = fmt.Sprintf("%s,%s", strconv.FormatFloat(timestamp, 'f', -1, 64), uuid)
-		}
+func (it *PageIterator[T]) Iterate() iter.Seq2[*T, error] {
+	if it.exhaustive {
+		return it.iterateExhaustive()
+	}
+	if it.prefetch > 0 {
+		return it.iteratePrefetch()
 	}
 
-	it.request.SetQueryParam("search_after", it.searchAfter)
+	return func(yield func(*T, error) bool) {
+		// make at least one request so Total and HasMore are populated
+		for first := it.requestFirstPage(); first || it.count < it.limit || it.all; first = false {
+			items, err := it.getMoreResults()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
 
-	// set HasMore
-	if r.Total != MaxTotal {
-		it.HasMore = r.Total > (it.count + len(r.Results))
-	} else {
-		it.HasMore = len(r.Results) >= it.size
-	}
+			for i, item := range items {
+				if !yield(item, nil) {
+					return
+				}
 
-	return results, nil
-}
+				it.count++
+				if !it.all && it.count >= it.limit {
+					// the rest of the page is left
+					it.HasMore = it.HasMore || i < len(items)-1
+					return
+				}
+			}
 
-func (it *Iterator) Iterate() iter.Seq2[*SearchResult, error] {
-	return func(yield func(*SearchResult, error) bool) {
-		// always make at least one request so Total and HasMore are populated,
-		for first := true; first || it.count < it.limit || it.all; first = false {
-			results, err := it.getMoreResults()
+			err = it.saveCheckpoint()
 			if err != nil {
 				yield(nil, err)
 				return
 			}
 
-			for _, result := range results {
-				if !yield(result, nil) {
+			if len(items) == 0 || !it.HasMore {
+				return
+			}
+		}
+	}
+}
+
+// iteratePrefetch fetches up to it.prefetch pages ahead in a background goroutine while
+// the consumer processes the current page. Pages are still requested one by one since
+// each page depends on the cursor of the previous one. The iterator state (HasMore,
+// Total) reflects the pages handed to the consumer, not the prefetched ones.
+func (it *PageIterator[T]) iteratePrefetch() iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		base := it.ctx
+		if base == nil {
+			base = context.Background()
+		}
+		ctx, cancel := context.WithCancel(base)
+		// the producer holds a page while it's blocked on sending it, so the buffer is one page
+		// shorter than the depth to fetch up to it.prefetch pages ahead of the consumer
+		pages := make(chan pageResult[T], it.prefetch-1)
+		var wg sync.WaitGroup
+
+		defer func() {
+			// stop the producer (and its in-flight request) and wait for it
+			// so the iterator is not mutated after Iterate returns
+			cancel()
+			wg.Wait()
+			it.request.SetContext(it.ctx)
+		}()
+
+		wg.Go(func() {
+			defer close(pages)
+
+			cursor, fetched := it.cursor, it.count
+			for first := it.requestFirstPage(); first || fetched < it.limit || it.all; first = false {
+				page, err := it.fetchPage(ctx, cursor, fetched)
+				select {
+				case pages <- pageResult[T]{page: page, err: err}:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil || len(page.Items) == 0 || !page.HasMore {
+					return
+				}
+				cursor = page.Cursor
+				fetched += len(page.Items)
+			}
+		})
+
+		for result := range pages {
+			if result.err != nil {
+				yield(nil, result.err)
+				return
+			}
+			it.apply(result.page)
+
+			for i, item := range result.page.Items {
+				if !yield(item, nil) {
 					return
 				}
 
 				it.count++
 				if !it.all && it.count >= it.limit {
+					// the rest of the page is left
+					it.HasMore = it.HasMore || i < len(result.page.Items)-1
 					return
 				}
 			}
 
-			if len(results) == 0 || !it.HasMore {
+			err := it.saveCheckpoint()
+			if err != nil {
+				yield(nil, err)
 				return
 			}
 		}
 	}
 }
 
-func (c *Client) Search(q string, options ...IteratorOption) (*Iterator, error) {
+func (c *Client) Search(q string, options ...IteratorOption) (*SearchIterator, error) {
 	options = append(options, IteratorQuery(q))
-	return newIterator(c, PrefixedPath("/search"), options...)
+	return newIterator(c, PrefixedPath("/search"), Paginator[SearchResult](SearchAfterPaginator{}), options...)
 }
 
-func (c *Client) StructureSearch(uuid string, options ...IteratorOption) (*Iterator, error) {
-	return newIterator(c, PrefixedPath(fmt.Sprintf("/pro/result/%s/similar/", uuid)), options...)
+func (c *Client) StructureSearch(uuid string, options ...IteratorOption) (*SearchIterator, error) {
+	return newIterator(c, PrefixedPath(fmt.Sprintf("/pro/result/%s/similar/", uuid)), Paginator[SearchResult](SearchAfterPaginator{}), options...)
 }
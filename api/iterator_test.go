@@ -1,7 +1,11 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
@@ -72,3 +76,267 @@ func TestSearchWithSizeZero(t *testing.T) {
 	assert.Equal(t, 42, it.Total)
 	assert.True(t, gock.IsDone())
 }
+
+func TestSearchWithPrefetch(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return !req.URL.Query().Has("search_after"), nil
+		}).
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[1,"dummy"]},{"sort":[2,"dummy"]}], "total": 5, "has_more": true}`)
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		MatchParam("search_after", "2,dummy").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[3,"dummy"]},{"sort":[4,"dummy"]}], "total": 5, "has_more": true}`)
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		MatchParam("search_after", "4,dummy").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[5,"dummy"]}], "total": 5, "has_more": false}`)
+
+	c := newTestClient()
+	it, err := c.Search("test", IteratorSize(2), IteratorAll(true), IteratorPrefetch(2))
+	assert.NoError(t, err)
+
+	var sorts []any
+	for result, err := range it.Iterate() {
+		assert.NoError(t, err)
+		sorts = append(sorts, result.Sort[0])
+	}
+	assert.Equal(t, []any{1.0, 2.0, 3.0, 4.0, 5.0}, sorts)
+	assert.Equal(t, 5, it.Total)
+	assert.False(t, it.HasMore)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSearchWithPrefetchError(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return !req.URL.Query().Has("search_after"), nil
+		}).
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[1,"dummy"]}], "total": 3, "has_more": true}`)
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		MatchParam("search_after", "1,dummy").
+		Reply(http.StatusNotFound).
+		JSON(map[string]any{"status": 404, "message": "Not Found"})
+
+	c := newTestClient()
+	it, err := c.Search("test", IteratorSize(1), IteratorAll(true), IteratorPrefetch(1))
+	assert.NoError(t, err)
+
+	count := 0
+	var iterErr error
+	for result, err := range it.Iterate() {
+		if err != nil {
+			iterErr = err
+			break
+		}
+		assert.NotNil(t, result)
+		count++
+	}
+	// the results of the page before the error are yielded first
+	assert.Equal(t, 1, count)
+	assert.ErrorIs(t, iterErr, ErrNotFound)
+}
+
+func TestSearchWithPrefetchLimit(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[1,"dummy"]},{"sort":[2,"dummy"]}], "total": 100, "has_more": true}`)
+
+	c := newTestClient()
+	it, err := c.Search("test", IteratorSize(2), IteratorLimit(2), IteratorPrefetch(3))
+	assert.NoError(t, err)
+
+	count := 0
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 2, count)
+	assert.True(t, it.HasMore)
+	// no page beyond the limit is prefetched
+	assert.True(t, gock.IsDone())
+	assert.False(t, gock.HasUnmatchedRequest())
+}
+
+func TestSearchWithPrefetchBreak(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		Persist().
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[1,"dummy"]},{"sort":[2,"dummy"]}], "total": 100, "has_more": true}`)
+
+	c := newTestClient()
+	it, err := c.Search("test", IteratorSize(2), IteratorAll(true), IteratorPrefetch(2))
+	assert.NoError(t, err)
+
+	count := 0
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestIteratorPrefetchInvalid(t *testing.T) {
+	c := newTestClient()
+	_, err := c.Search("test", IteratorPrefetch(-1))
+	assert.Error(t, err)
+}
+
+func TestSearchCheckpointAndResume(t *testing.T) {
+	for _, prefetch := range []int{0, 1} {
+		t.Run(fmt.Sprintf("prefetch %d", prefetch), func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New("http://testserver/").
+				Get("/api/v1/search").
+				MatchParam("q", "test").
+				MatchParam("search_after", "1,dummy").
+				Reply(200).
+				SetHeader("Content-Type", "application/json").
+				BodyString(`{"results":[{"sort":[2,"dummy"]}], "total": 3, "has_more": true}`)
+
+			gock.New("http://testserver/").
+				Get("/api/v1/search").
+				MatchParam("q", "test").
+				MatchParam("search_after", "2,dummy").
+				Reply(200).
+				SetHeader("Content-Type", "application/json").
+				BodyString(`{"results":[{"sort":[3,"dummy"]}], "total": 3, "has_more": false}`)
+
+			c := newTestClient()
+			var checkpoints []Checkpoint
+			it, err := c.Search("test",
+				IteratorSize(1),
+				IteratorAll(true),
+				IteratorPrefetch(prefetch),
+				IteratorResume(Checkpoint{Cursor: "1,dummy", Count: 1, Total: 3, HasMore: true}),
+				IteratorCheckpoint(func(cp Checkpoint) error {
+					checkpoints = append(checkpoints, cp)
+					return nil
+				}),
+			)
+			assert.NoError(t, err)
+
+			count := 0
+			for _, err := range it.Iterate() {
+				assert.NoError(t, err)
+				count++
+			}
+			assert.Equal(t, 2, count)
+			assert.Equal(t, []Checkpoint{
+				{Cursor: "2,dummy", Count: 2, Total: 3, HasMore: true},
+				{Cursor: "3,dummy", Count: 3, Total: 3, HasMore: false},
+			}, checkpoints)
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
+
+func TestSearchCheckpointError(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		MatchParam("q", "test").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[1,"dummy"]}], "total": 3, "has_more": true}`)
+
+	c := newTestClient()
+	it, err := c.Search("test", IteratorSize(1), IteratorAll(true), IteratorCheckpoint(func(cp Checkpoint) error {
+		return assert.AnError
+	}))
+	assert.NoError(t, err)
+
+	var iterErr error
+	for _, err := range it.Iterate() {
+		if err != nil {
+			iterErr = err
+		}
+	}
+	assert.ErrorIs(t, iterErr, assert.AnError)
+}
+
+func TestIteratorFunc(t *testing.T) {
+	c := newTestClient()
+
+	var it *Iterator
+	it, err := c.Search("test", IteratorFunc(func(it *Iterator) error {
+		it.Total = 10
+		return nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, it.Total)
+
+	var opts []HostnameIteratorOption
+	opts = append(opts, IteratorFunc(func(it *Iterator) error { return nil }))
+	_, err = c.IterateHostname("example.com", opts...)
+	assert.Error(t, err)
+}
+
+func TestSearchWithPrefetchDepth(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		Persist().
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":[{"sort":[1,"dummy"]}], "total": 100, "has_more": true}`)
+
+	for _, depth := range []int{1, 3} {
+		var requests atomic.Int32
+		c := newTestClient().Use(func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requests.Add(1)
+				return next.RoundTrip(req)
+			})
+		})
+		it, err := c.Search("test", IteratorSize(1), IteratorAll(true), IteratorPrefetch(depth))
+		assert.NoError(t, err)
+
+		for _, err := range it.Iterate() {
+			assert.NoError(t, err)
+			// the current page and up to depth pages ahead are fetched while the consumer is busy
+			time.Sleep(100 * time.Millisecond)
+			assert.Equal(t, int32(1+depth), requests.Load())
+			break
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultKeyCooldown is the cooldown of a rate limited key when the response has no reset headers.
+const DefaultKeyCooldown = 60 * time.Second
+
+// keyFingerprint returns a short non-reversible identifier of an API key for logging and bucketing.
+func keyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+type keyPoolContextKey struct{}
+
+// KeyPool is a pool of API keys with separate quotas. Keys are used in round-robin order
+// and a key is skipped while it's cooling down after a response signals quota exhaustion
+// or a per-key rate limit. Cooldowns are tracked per rate limit action (search, scan, etc.).
+type KeyPool struct {
+	// Logger is the logger for rotation messages. The package default logger is used if it's nil.
+	Logger    *slog.Logger
+	mu        sync.Mutex
+	keys      []string
+	next      int
+	cooldowns map[string]time.Time
+	now       func() time.Time
+}
+
+func NewKeyPool(keys ...string) *KeyPool {
+	return &KeyPool{
+		Logger:    nil,
+		mu:        sync.Mutex{},
+		keys:      keys,
+		next:      0,
+		cooldowns: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+func (p *KeyPool) Keys() []string {
+	return p.keys
+}
+
+func (p *KeyPool) Len() int {
+	return len(p.keys)
+}
+
+func cooldownKey(key, action string) string {
+	return key + "\x00" + action
+}
+
+// pick returns the next key for the action which is not cooling down. If all keys are
+// cooling down, it returns the key which becomes available first and false.
+func (p *KeyPool) pick(action string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var soonest string
+	var soonestAt time.Time
+	for i := range p.keys {
+		key := p.keys[(p.next+i)%len(p.keys)]
+		until := p.cooldowns[cooldownKey(key, action)]
+		if !now.Before(until) {
+			p.next = (p.next + i + 1) % len(p.keys)
+			return key, true
+		}
+		if soonest == "" || until.Before(soonestAt) {
+			soonest, soonestAt = key, until
+		}
+	}
+	return soonest, false
+}
+
+// Available reports whether a key for the action is not cooling down.
+func (p *KeyPool) Available(action string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for _, key := range p.keys {
+		if !now.Before(p.cooldowns[cooldownKey(key, action)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cooldown makes the key unavailable for the action until the time.
+func (p *KeyPool) Cooldown(key, action string, until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cooldowns[cooldownKey(key, action)] = until
+}
+
+// resetAt returns when the rate limit of a response is reset.
+func (p *KeyPool) resetAt(header http.Header) (time.Time, bool) {
+	// rate limit headers: https://urlscan.io/docs/api/#ratelimit
+	resetAfter, err := strconv.Atoi(header.Get("X-Rate-Limit-Reset-After"))
+	if err == nil {
+		return p.now().Add(time.Duration(resetAfter) * time.Second), true
+	}
+	reset, err := time.Parse(time.RFC3339, header.Get("X-Rate-Limit-Reset"))
+	if err == nil {
+		return reset, true
+	}
+	return time.Time{}, false
+}
+
+// update puts the key on cooldown if the response is rate limited or the quota is used up.
+func (p *KeyPool) update(key, action string, res *http.Response) {
+	exhausted := res.StatusCode == http.StatusTooManyRequests || res.Header.Get("X-Rate-Limit-Remaining") == "0"
+	if !exhausted {
+		return
+	}
+
+	until, ok := p.resetAt(res.Header)
+	if !ok {
+		if res.StatusCode != http.StatusTooManyRequests {
+			return
+		}
+		until = p.now().Add(DefaultKeyCooldown)
+	}
+	p.Cooldown(key, action, until)
+
+	loggerOrDefault(p.Logger).Debug("API key is cooling down",
+		"key", keyFingerprint(key), "action", action, "until", until.Format(time.RFC3339))
+}
+
+// KeyPoolTransport sets an API key from Pool to each request. A rate limited request
+// is resent immediately with another key if any key is available, otherwise the
+// rate limited response is returned as is (and the retry stage waits for the reset).
+type KeyPoolTransport struct {
+	Transport http.RoundTripper
+	Pool      *KeyPool
+}
+
+func (t *KeyPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Pool == nil || t.Pool.Len() == 0 {
+		return t.Transport.RoundTrip(req)
+	}
+
+	action := rateLimitAction(apiPath(req))
+	for attempt := 1; ; attempt++ {
+		key, _ := t.Pool.pick(action)
+
+		// mark the request to make the rate limiter bucket it by the key
+		attemptReq := req.Clone(context.WithValue(req.Context(), keyPoolContextKey{}, key))
+		attemptReq.Header.Set("API-Key", key)
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("cannot rotate API key: request body is not replayable")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		res, err := t.Transport.RoundTrip(attemptReq)
+		if err != nil {
+			return res, err
+		}
+		t.Pool.update(key, action, res)
+
+		if res.StatusCode != http.StatusTooManyRequests || attempt >= t.Pool.Len() || !t.Pool.Available(action) {
+			return res, nil
+		}
+
+		loggerOrDefault(t.Pool.Logger).Info("API key is rate limited, rotating to the next key",
+			"key", keyFingerprint(key), "action", action)
+		requestStatsFromContext(req.Context()).addRetry()
+		drainBody(res)
+	}
+}
+
+func (t *KeyPoolTransport) setLogger(logger *slog.Logger) {
+	if t.Pool != nil {
+		t.Pool.Logger = logger
+	}
+}
+
+// SetKeyPool rotates API keys of the pool on quota exhaustion or per-key rate limits.
+// The key pool stage is above the cache and rate limit stages so responses are cached
+// and requests are paced per key.
+// Pass nil to disable it.
+func (c *Client) SetKeyPool(pool *KeyPool) *Client {
+	if pool == nil {
+		c.stage(stageKeyPool).set(nil)
+		return c
+	}
+	if pool.Logger == nil {
+		pool.Logger = c.logger
+	}
+	if pool.Len() > 0 {
+		c.SetAPIKey(pool.Keys()[0])
+	}
+	c.stage(stageKeyPool).set(KeyPoolMiddleware(pool))
+	return c
+}
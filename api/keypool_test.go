@@ -0,0 +1,190 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPoolPick(t *testing.T) {
+	now := time.Now()
+	p := NewKeyPool("a", "b", "c")
+	p.now = func() time.Time { return now }
+
+	// round-robin
+	for _, want := range []string{"a", "b", "c", "a"} {
+		key, ok := p.pick("search")
+		assert.True(t, ok)
+		assert.Equal(t, want, key)
+	}
+
+	// "b" is skipped while cooling down, only for the action
+	p.Cooldown("b", "search", now.Add(time.Minute))
+	for _, want := range []string{"c", "a", "c"} {
+		key, ok := p.pick("search")
+		assert.True(t, ok)
+		assert.Equal(t, want, key)
+	}
+	for _, want := range []string{"a", "b"} {
+		key, ok := p.pick("scan")
+		assert.True(t, ok)
+		assert.Equal(t, want, key)
+	}
+
+	// all keys are cooling down, the soonest one is returned
+	p.Cooldown("a", "search", now.Add(3*time.Minute))
+	p.Cooldown("c", "search", now.Add(2*time.Minute))
+	assert.False(t, p.Available("search"))
+	key, ok := p.pick("search")
+	assert.False(t, ok)
+	assert.Equal(t, "b", key)
+
+	now = now.Add(time.Minute)
+	assert.True(t, p.Available("search"))
+}
+
+func TestKeyPoolUpdate(t *testing.T) {
+	now := time.Now()
+	p := NewKeyPool("a")
+	p.now = func() time.Time { return now }
+
+	newResponse := func(status int, headers map[string]string) *http.Response {
+		header := make(http.Header)
+		for k, v := range headers {
+			header.Set(k, v)
+		}
+		return &http.Response{StatusCode: status, Header: header} //nolint:exhaustruct
+	}
+
+	p.update("a", "search", newResponse(http.StatusOK, map[string]string{"X-Rate-Limit-Remaining": "10", "X-Rate-Limit-Reset-After": "30"}))
+	assert.True(t, p.Available("search"))
+
+	// the quota is used up
+	p.update("a", "search", newResponse(http.StatusOK, map[string]string{"X-Rate-Limit-Remaining": "0", "X-Rate-Limit-Reset-After": "30"}))
+	assert.Equal(t, now.Add(30*time.Second), p.cooldowns[cooldownKey("a", "search")])
+
+	// rate limited without reset headers
+	p.update("a", "scan", newResponse(http.StatusTooManyRequests, nil))
+	assert.Equal(t, now.Add(DefaultKeyCooldown), p.cooldowns[cooldownKey("a", "scan")])
+
+	reset := now.Add(time.Hour).Truncate(time.Second).UTC()
+	p.update("a", "result", newResponse(http.StatusTooManyRequests, map[string]string{"X-Rate-Limit-Reset": reset.Format(time.RFC3339)}))
+	assert.Equal(t, reset, p.cooldowns[cooldownKey("a", "result")])
+}
+
+func TestKeyPoolTransport(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Post("/api/v1/scan/").
+		MatchHeader("API-Key", "^a$").
+		Times(1).
+		Reply(http.StatusTooManyRequests).
+		SetHeader("X-Rate-Limit-Reset-After", "3600").
+		JSON(map[string]any{"status": 429, "message": "Daily quota exceeded"})
+	gock.New("http://testserver/").
+		Post("/api/v1/scan/").
+		MatchHeader("API-Key", "^b$").
+		Times(2).
+		Reply(http.StatusOK).
+		JSON(map[string]string{"uuid": "dummy"})
+
+	c := newTestClient()
+	pool := NewKeyPool("a", "b")
+	c.SetKeyPool(pool)
+	assert.Equal(t, "a", c.APIKey)
+
+	// rotated to "b" immediately
+	resp, err := c.NewRequest().SetBodyJSONBytes([]byte(`{"url":"https://example.com"}`)).Post("/api/v1/scan/")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// "a" is cooling down
+	_, err = c.NewRequest().SetBodyJSONBytes([]byte(`{"url":"https://example.com"}`)).Post("/api/v1/scan/")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	// retry -> key pool -> rate limit
+	assert.Equal(t, []string{stageRetry, stageKeyPool, stageRateLimit}, activeStages(c))
+	keyPoolTransport, ok := c.stage(stageKeyPool).handler.(*KeyPoolTransport)
+	assert.True(t, ok)
+	assert.Same(t, pool, keyPoolTransport.Pool)
+}
+
+func TestKeyPoolRateLimitBuckets(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search/").
+		Times(2).
+		Reply(http.StatusOK).
+		SetHeaders(map[string]string{
+			"X-Rate-Limit-Limit":     "120",
+			"X-Rate-Limit-Remaining": "100",
+			"X-Rate-Limit-Window":    "minute",
+		}).
+		JSON(map[string]any{"results": []any{}})
+
+	limiter := NewRateLimiter()
+	c := newTestClient().SetKeyPool(NewKeyPool("a", "b")).SetRateLimiter(limiter)
+	for range 2 {
+		_, err := c.NewRequest().Get("/api/v1/search/")
+		assert.NoError(t, err)
+	}
+	assert.True(t, gock.IsDone())
+
+	// paced per key
+	assert.Len(t, limiter.buckets, 2)
+	assert.Contains(t, limiter.buckets, "search@"+keyFingerprint("a"))
+	assert.Contains(t, limiter.buckets, "search@"+keyFingerprint("b"))
+}
+
+func TestKeyPoolWithCache(t *testing.T) {
+	defer gock.Off()
+
+	uuid := "68e26c59-2eae-437b-aeb1-cf750fafe7d7"
+	gock.New("http://testserver/").
+		Get("/api/v1/result/"+uuid+"/").
+		MatchHeader("API-Key", "^a$").
+		Times(1).
+		Reply(http.StatusOK).
+		SetHeader("X-Rate-Limit-Remaining", "0").
+		SetHeader("X-Rate-Limit-Reset-After", "60").
+		JSON(map[string]string{"key": "a"})
+	gock.New("http://testserver/").
+		Get("/api/v1/result/"+uuid+"/").
+		MatchHeader("API-Key", "^b$").
+		Times(1).
+		Reply(http.StatusOK).
+		JSON(map[string]string{"key": "b"})
+
+	c := newTestClient().SetRateLimiter(nil)
+	pool := NewKeyPool("a", "b")
+	c.SetKeyPool(pool)
+	c.SetCache(NewCache(NewDiskCache(t.TempDir(), 0)))
+
+	// the responses are cached by the key which was picked
+	resp, err := c.NewRequest().Get("/api/v1/result/" + uuid + "/")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"key":"a"}`, string(resp.body))
+	resp, err = c.NewRequest().Get("/api/v1/result/" + uuid + "/")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"key":"b"}`, string(resp.body))
+	assert.True(t, gock.IsDone())
+
+	// "a" is cooling down, so "b" is picked and its cached response is served
+	resp, err = c.NewRequest().Get("/api/v1/result/" + uuid + "/")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"key":"b"}`, string(resp.body))
+
+	// a cached response doesn't put the key on cooldown
+	pool.Cooldown("a", "result", time.Time{})
+	for range 2 {
+		_, err = c.NewRequest().Get("/api/v1/result/" + uuid + "/")
+		assert.NoError(t, err)
+	}
+	assert.True(t, pool.cooldowns[cooldownKey("a", "result")].IsZero())
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"slices"
@@ -66,7 +67,7 @@ func WithLiveScanScannerDisableFeatures(features []string) LiveScanOption {
 	return func(opts *LiveScanOptions) {
 		for _, feature := range features {
 			if slices.Contains(DisabledFeatures, feature) {
-				log.Warn(fmt.Sprintf("The feature '%s' is deprecated", feature))
+				DefaultLogger().Warn(fmt.Sprintf("The feature '%s' is deprecated", feature))
 			}
 		}
 		opts.Scanner.DisableFeatures = features
@@ -104,29 +105,41 @@ func newLiveScanStoreOptions(opts ...LiveScanStoreOption) *LiveScanStoreOptions
 }
 
 func (c *Client) TriggerNonBlockingLiveScan(id string, opts ...LiveScanOption) (*Response, error) {
+	return c.TriggerNonBlockingLiveScanContext(context.Background(), id, opts...)
+}
+
+func (c *Client) TriggerNonBlockingLiveScanContext(ctx context.Context, id string, opts ...LiveScanOption) (*Response, error) {
 	liveScanOpts := newLiveScanOptions(opts...)
 	marshalled, err := json.Marshal(liveScanOpts)
 	if err != nil {
 		return nil, err
 	}
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Post(PrefixedPath(fmt.Sprintf("/livescan/%s/task/", id)))
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Post(PrefixedPath(fmt.Sprintf("/livescan/%s/task/", id)))
 }
 
 func (c *Client) TriggerLiveScan(id string, opts ...LiveScanOption) (*Response, error) {
+	return c.TriggerLiveScanContext(context.Background(), id, opts...)
+}
+
+func (c *Client) TriggerLiveScanContext(ctx context.Context, id string, opts ...LiveScanOption) (*Response, error) {
 	liveScanOpts := newLiveScanOptions(opts...)
 	marshalled, err := json.Marshal(liveScanOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Post(PrefixedPath(fmt.Sprintf("/livescan/%s/scan/", id)))
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Post(PrefixedPath(fmt.Sprintf("/livescan/%s/scan/", id)))
 }
 
 func (c *Client) StoreLiveScanResult(scannerId string, scanId string, opts ...LiveScanStoreOption) (*Response, error) {
+	return c.StoreLiveScanResultContext(context.Background(), scannerId, scanId, opts...)
+}
+
+func (c *Client) StoreLiveScanResultContext(ctx context.Context, scannerId string, scanId string, opts ...LiveScanStoreOption) (*Response, error) {
 	liveScanStoreOpts := newLiveScanStoreOptions(opts...)
 	marshalled, err := json.Marshal(liveScanStoreOpts)
 	if err != nil {
 		return nil, err
 	}
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Put(PrefixedPath(fmt.Sprintf("/livescan/%s/%s/", scannerId, scanId)))
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Put(PrefixedPath(fmt.Sprintf("/livescan/%s/%s/", scannerId, scanId)))
 }
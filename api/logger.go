@@ -0,0 +1,30 @@
+package api
+
+import (
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+var defaultLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	defaultLogger.Store(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+}
+
+// SetDefaultLogger sets the logger used by clients and transports that don't have their own logger.
+func SetDefaultLogger(logger *slog.Logger) {
+	defaultLogger.Store(logger)
+}
+
+// DefaultLogger returns the package default logger.
+func DefaultLogger() *slog.Logger {
+	return defaultLogger.Load()
+}
+
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return DefaultLogger()
+	}
+	return logger
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLogger(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusTooManyRequests).
+		SetHeaders(map[string]string{"X-Rate-Limit-Reset-After": "0"})
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	c := newTestClient().SetLogger(logger)
+	_, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"msg":"Sleeping for 0 seconds"`)
+	assert.Equal(t, logger, c.Logger())
+}
+
+func TestDefaultLogger(t *testing.T) {
+	original := DefaultLogger()
+	defer SetDefaultLogger(original)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	SetDefaultLogger(logger)
+
+	c := newTestClient()
+	assert.Equal(t, logger, c.Logger())
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// Middleware wraps the next stage of the request pipeline of a client. It's called once,
+// when it's set as a stage, and the returned RoundTripper handles all the requests.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to use an ordinary function as an http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Built-in stages of the request pipeline, from the outermost. A request goes through:
+//
+//  1. user-agent, api-key and compression, which set the request headers
+//  2. the middlewares added by Use, in the order they were added
+//  3. cassette, which records or replays the interactions (see SetCassette)
+//  4. retry, which retries the request on transient errors (see SetRetryPolicy)
+//  5. key-pool, which sets an API key of the pool to each attempt (see SetKeyPool)
+//  6. cache, which serves cached responses by the API key set (see SetCache)
+//  7. rate-limit, which paces the requests going out (see SetRateLimiter)
+//  8. debug, which dumps the requests going out (see SetDebugHTTP)
+//
+// and then it's sent by the HTTP transport (see SetHTTPTransport). A stage without a
+// handler (e.g. the cache of a client without a cache) passes the request through.
+const (
+	stageUserAgent   = "user-agent"
+	stageAPIKey      = "api-key"
+	stageCompression = "compression"
+	stageCassette    = "cassette"
+	stageRetry       = "retry"
+	stageKeyPool     = "key-pool"
+	stageCache       = "cache"
+	stageRateLimit   = "rate-limit"
+	stageDebug       = "debug"
+)
+
+var builtinStages = []string{
+	stageUserAgent,
+	stageAPIKey,
+	stageCompression,
+	stageCassette,
+	stageRetry,
+	stageKeyPool,
+	stageCache,
+	stageRateLimit,
+	stageDebug,
+}
+
+// stage is a stage of the request pipeline. The middlewares added by Use have no name.
+type stage struct {
+	name    string
+	handler http.RoundTripper
+	// next is the next stage, or the HTTP client for the last one
+	next http.RoundTripper
+}
+
+func (s *stage) RoundTrip(req *http.Request) (*http.Response, error) {
+	if s.handler == nil {
+		return s.next.RoundTrip(req)
+	}
+	return s.handler.RoundTrip(req)
+}
+
+// set replaces the handler of the stage with the one built by mw. A nil mw removes the handler.
+func (s *stage) set(mw Middleware) {
+	if mw == nil {
+		s.handler = nil
+		return
+	}
+	// resolve the next stage per request so the stages inserted later are taken into account
+	s.handler = mw(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return s.next.RoundTrip(req)
+	}))
+}
+
+// newStages sets up the built-in stages without handlers.
+func (c *Client) newStages() {
+	c.middlewares = make([]*stage, 0, len(builtinStages))
+	for _, name := range builtinStages {
+		c.middlewares = append(c.middlewares, &stage{name: name, handler: nil, next: nil})
+	}
+	c.linkStages()
+}
+
+// linkStages links each stage to the next one and the last one to the HTTP client.
+func (c *Client) linkStages() {
+	for i, s := range c.middlewares {
+		if i+1 < len(c.middlewares) {
+			s.next = c.middlewares[i+1]
+		} else {
+			s.next = RoundTripperFunc(c.send)
+		}
+	}
+}
+
+// send sends the request with the HTTP client.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	return c.httpClient.Do(req)
+}
+
+// stage returns the built-in stage of the name.
+func (c *Client) stage(name string) *stage {
+	i := slices.IndexFunc(c.middlewares, func(s *stage) bool { return s.name == name })
+	return c.middlewares[i]
+}
+
+// Use adds middlewares (e.g. auditing or metrics) to the request pipeline of the client.
+// They are placed after the headers are set and before the cassette and retry stages
+// (see the order of the built-in stages above), in the order they were added. So a middleware
+// sees each call of Do once with the headers set. Use is not safe to call concurrently with requests.
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	for _, mw := range middlewares {
+		s := &stage{name: "", handler: nil, next: nil}
+		i := slices.IndexFunc(c.middlewares, func(s *stage) bool { return s.name == stageCassette })
+		c.middlewares = slices.Insert(c.middlewares, i, s)
+		c.linkStages()
+		s.set(mw)
+	}
+	return c
+}
+
+// loggerSetter is implemented by the built-in stages which log.
+type loggerSetter interface {
+	setLogger(logger *slog.Logger)
+}
+
+// RetryMiddleware retries a request failed with a transient error according to the policy.
+func RetryMiddleware(policy *RetryPolicy, logger *slog.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &RetryTransport{Transport: next, Policy: policy, Logger: logger}
+	}
+}
+
+// KeyPoolMiddleware sets an API key of the pool to each request and rotates the keys.
+func KeyPoolMiddleware(pool *KeyPool) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &KeyPoolTransport{Transport: next, Pool: pool}
+	}
+}
+
+// CacheMiddleware serves cacheable responses from the cache.
+func CacheMiddleware(cache *Cache) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &CacheTransport{Transport: next, Cache: cache}
+	}
+}
+
+// RateLimitMiddleware paces requests with the rate limiter.
+func RateLimitMiddleware(limiter *RateLimiter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &RateLimitTransport{Transport: next, Limiter: limiter}
+	}
+}
+
+// DebugMiddleware dumps request/response pairs to w with bodies truncated to maxBodySize bytes.
+func DebugMiddleware(w io.Writer, maxBodySize int) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return NewDebugTransport(next, w, maxBodySize)
+	}
+}
+
+// CassetteMiddleware records interactions with the cassette, or replays them. A recorder
+// without a transport records the interactions sent to the next stage.
+func CassetteMiddleware(cassette *CassetteTransport) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if cassette.Mode == CassetteRecord && cassette.Transport == nil {
+			cassette.Transport = next
+		}
+		return cassette
+	}
+}
+
+// setHeader returns a copy of req with the header set, as a RoundTripper must not modify the request.
+func setHeader(req *http.Request, key, value string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set(key, value)
+	return req
+}
+
+// userAgentMiddleware sets the User-Agent header to the agent of the client.
+func (c *Client) userAgentMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return next.RoundTrip(setHeader(req, "User-Agent", c.Agent))
+	})
+}
+
+// apiKeyMiddleware sets the API-Key header to the API key of the client.
+func (c *Client) apiKeyMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return next.RoundTrip(setHeader(req, "API-Key", c.APIKey))
+	})
+}
+
+// compressionMiddleware asks for an uncompressed response if the compression is disabled.
+// An explicit Accept-Encoding also stops the HTTP transport from decompressing the response.
+func (c *Client) compressionMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if c.disableCompression {
+			req = setHeader(req, "Accept-Encoding", "identity")
+		}
+		return next.RoundTrip(req)
+	})
+}
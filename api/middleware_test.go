@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+// activeStages returns the built-in stages with a handler except the ones setting the headers.
+func activeStages(c *Client) []string {
+	var names []string
+	for _, s := range c.middlewares {
+		switch s.name {
+		case "", stageUserAgent, stageAPIKey, stageCompression:
+			continue
+		}
+		if s.handler != nil {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+func TestUseOrder(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	var calls []string
+	newMiddleware := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				res, err := next.RoundTrip(req)
+				calls = append(calls, name+" after")
+				return res, err
+			})
+		}
+	}
+
+	c := newTestClient().Use(newMiddleware("first"), newMiddleware("second"))
+	c.Use(newMiddleware("third"))
+
+	_, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"first before", "second before", "third before",
+		"third after", "second after", "first after",
+	}, calls)
+	assert.True(t, gock.IsDone())
+}
+
+func TestUseSeesHeadersAndCallsOnce(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusBadGateway)
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	built := 0
+	var requests []*http.Request
+	c := newTestClient().SetRetryPolicy(newTestRetryPolicy())
+	c.Use(func(next http.RoundTripper) http.RoundTripper {
+		built++
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req)
+			return next.RoundTrip(req)
+		})
+	})
+
+	c.SetAgent("test-agent")
+	_, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	_, err = c.NewRequest().Get("/bar")
+	assert.Error(t, err)
+
+	// the middleware is built once and the retries are beneath it
+	assert.Equal(t, 1, built)
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "dummy", requests[0].Header.Get("API-Key"))
+	assert.Equal(t, "test-agent", requests[0].Header.Get("User-Agent"))
+}
+
+func TestDisableCompression(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		MatchHeader("Accept-Encoding", "identity").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	c := newTestClient().SetDisableCompression(true)
+	_, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSetStageInPlace(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	var calls []string
+	c := newTestClient().Use(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls = append(calls, "middleware")
+			return next.RoundTrip(req)
+		})
+	})
+
+	// the stages are replaced in place and the middleware stays above them
+	var buf bytes.Buffer
+	c.SetDebugHTTP(&buf, 0).SetRateLimiter(nil).SetRetryPolicy(newTestRetryPolicy())
+	c.SetDebugHTTP(&buf, 0)
+	assert.Equal(t, []string{stageRetry, stageDebug}, activeStages(c))
+
+	_, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"middleware"}, calls)
+	assert.Equal(t, 1, strings.Count(buf.String(), "> GET http://testserver/bar\n"))
+	assert.True(t, gock.IsDone())
+}
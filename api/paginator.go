@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// PageRequest describes the page an iterator requests.
+type PageRequest struct {
+	// Cursor is the cursor of the page. It's empty for the first page.
+	Cursor string
+	// Size is the number of items per page.
+	Size int
+	// Fetched is the number of items fetched before the page.
+	Fetched int
+}
+
+// Page is a decoded page of items.
+type Page[T any] struct {
+	Items []*T
+	// Cursor is the cursor of the next page.
+	Cursor  string
+	Total   int
+	HasMore bool
+	// Raw is the body of the page.
+	Raw json.RawMessage
+}
+
+// Paginator is the cursor strategy of a paginated endpoint.
+type Paginator[T any] interface {
+	// Prepare sets the query parameters of req for the page.
+	Prepare(req *Request, page PageRequest)
+	// Decode decodes the response of the page.
+	Decode(resp *Response, page PageRequest) (*Page[T], error)
+}
+
+// totalless is implemented by a Paginator whose pages have no total. The first page of
+// it is requested only if an item is wanted, so a limit of 0 makes no request.
+type totalless interface {
+	totalless()
+}
+
+// itemKeyer is implemented by a Paginator whose items have a unique key to de-duplicate them.
+type itemKeyer[T any] interface {
+	Key(item *T) string
+}
+
+// SearchAfterPaginator paginates the search APIs with the search_after parameter,
+// which is the sort attribute of the last result of the previous page.
+type SearchAfterPaginator struct{}
+
+func (SearchAfterPaginator) Prepare(req *Request, page PageRequest) {
+	if page.Size >= 0 {
+		req.SetQueryParam("size", strconv.Itoa(page.Size))
+	}
+	// the request is reused for all the pages, so the cursor of a previous iteration is cleared
+	if page.Cursor != "" {
+		req.SetQueryParam("search_after", page.Cursor)
+	} else {
+		req.DelQueryParam("search_after")
+	}
+}
+
+func (SearchAfterPaginator) Decode(resp *Response, page PageRequest) (*Page[SearchResult], error) {
+	var r SearchResults
+	err := resp.Unmarshal(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Page[SearchResult]{Items: nil, Cursor: page.Cursor, Total: r.Total, HasMore: false, Raw: r.Raw}
+	for _, result := range r.Results {
+		p.Items = append(p.Items, &result)
+	}
+	// set search_after for the next request
+	if len(r.Results) > 0 {
+		last := r.Results[len(r.Results)-1]
+
+		if len(last.Sort) >= 2 {
+			timestamp, ok := last.Sort[0].(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid result sort format")
+			}
+
+			uuid, ok := last.Sort[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid result sort format")
+			}
+			p.Cursor = fmt.Sprintf("%s,%s", strconv.FormatFloat(timestamp, 'f', -1, 64), uuid)
+		}
+	}
+
+	// set HasMore
+	if r.Total != MaxTotal {
+		p.HasMore = r.Total > (page.Fetched + len(r.Results))
+	} else {
+		p.HasMore = len(r.Results) >= page.Size
+	}
+
+	return p, nil
+}
+
+// Key returns the UUID of a search result.
+func (SearchAfterPaginator) Key(result *SearchResult) string {
+	if len(result.Sort) < 2 {
+		return ""
+	}
+	return fmt.Sprint(result.Sort[1])
+}
+
+// PageStatePaginator paginates the hostname API with the pageState parameter.
+type PageStatePaginator struct{}
+
+func (PageStatePaginator) Prepare(req *Request, page PageRequest) {
+	// size (number of results per batch) is "limit" in this API endpoint
+	if page.Size > 0 {
+		req.SetQueryParam("limit", strconv.Itoa(page.Size))
+	}
+	// the request is reused for all the pages, so the cursor of a previous iteration is cleared
+	if page.Cursor != "" {
+		req.SetQueryParam("pageState", page.Cursor)
+	} else {
+		req.DelQueryParam("pageState")
+	}
+}
+
+func (PageStatePaginator) totalless() {}
+
+func (PageStatePaginator) Decode(resp *Response, page PageRequest) (*Page[json.RawMessage], error) {
+	var r HostnameResults
+	err := resp.Unmarshal(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Page[json.RawMessage]{Items: nil, Cursor: r.PageState, Total: 0, HasMore: false, Raw: r.Raw}
+	for _, result := range r.Results {
+		p.Items = append(p.Items, &result)
+	}
+	// update HasMore based on the number of results
+	p.HasMore = len(r.Results) >= page.Size
+	return p, nil
+}
+
+// ListPaginator decodes an endpoint which returns all items at once in the Field
+// of the response (e.g. {"channels": [...]}) as a single page.
+type ListPaginator[T any] struct {
+	Field string
+}
+
+func (ListPaginator[T]) Prepare(req *Request, page PageRequest) {}
+
+func (lp ListPaginator[T]) Decode(resp *Response, page PageRequest) (*Page[T], error) {
+	body, err := resp.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	var r map[string]json.RawMessage
+	err = json.Unmarshal(body, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	if raw, ok := r[lp.Field]; ok {
+		err = json.Unmarshal(raw, &items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Page[T]{Items: make([]*T, 0, len(items)), Cursor: "", Total: len(items), HasMore: false, Raw: body}
+	for i := range items {
+		p.Items = append(p.Items, &items[i])
+	}
+	return p, nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListChannels(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/user/channels/").
+		Persist().
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"channels":[{"_id":"a"},{"_id":"b"},{"_id":"c"}]}`)
+
+	c := newTestClient()
+
+	tests := []struct {
+		name        string
+		opts        []IteratorOption
+		wantIDs     []string
+		wantHasMore bool
+	}{
+		{name: "all", opts: []IteratorOption{IteratorAll(true)}, wantIDs: []string{"a", "b", "c"}, wantHasMore: false},
+		{name: "limit", opts: []IteratorOption{IteratorLimit(2)}, wantIDs: []string{"a", "b"}, wantHasMore: true},
+		{name: "limit over the list", opts: []IteratorOption{IteratorLimit(5)}, wantIDs: []string{"a", "b", "c"}, wantHasMore: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := c.ListChannels(tt.opts...)
+			assert.NoError(t, err)
+
+			var ids []string
+			for item, err := range it.Iterate() {
+				assert.NoError(t, err)
+				var channel struct {
+					ID string `json:"_id"`
+				}
+				assert.NoError(t, json.Unmarshal(*item, &channel))
+				ids = append(ids, channel.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, 3, it.Total)
+			assert.Equal(t, tt.wantHasMore, it.HasMore)
+		})
+	}
+}
+
+func TestListPaginatorMissingField(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/user/subscriptions/").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{}`)
+
+	c := newTestClient()
+	it, err := c.ListSubscriptions(IteratorAll(true))
+	assert.NoError(t, err)
+
+	count := 0
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 0, count)
+	assert.False(t, it.HasMore)
+}
+
+func TestHostnameCursor(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/hostname/example.com").
+		MatchParam("limit", "2").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"results":["a", "b"], "pageState": "page2"}`)
+
+	c := newTestClient()
+	it, err := c.IterateHostname("example.com", IteratorSize(2), IteratorLimit(2))
+	assert.NoError(t, err)
+
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "page2", it.Cursor())
+	assert.Equal(t, "page2", it.PageState())
+	assert.True(t, it.HasMore)
+}
+
+func TestPaginatorClearsStaleCursor(t *testing.T) {
+	c := newTestClient()
+	req := c.NewRequest()
+
+	PageStatePaginator{}.Prepare(req, PageRequest{Cursor: "page2", Size: 2, Fetched: 2})
+	assert.Equal(t, "page2", req.QueryParams["pageState"])
+	PageStatePaginator{}.Prepare(req, PageRequest{Cursor: "", Size: 2, Fetched: 0})
+	assert.NotContains(t, req.QueryParams, "pageState")
+
+	SearchAfterPaginator{}.Prepare(req, PageRequest{Cursor: "1,a", Size: 2, Fetched: 2})
+	assert.Equal(t, "1,a", req.QueryParams["search_after"])
+	SearchAfterPaginator{}.Prepare(req, PageRequest{Cursor: "", Size: 2, Fetched: 0})
+	assert.NotContains(t, req.QueryParams, "search_after")
+}
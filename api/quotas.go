@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Quota windows reported by the quotas endpoint.
+const (
+	QuotaMinute = "minute"
+	QuotaHour   = "hour"
+	QuotaDay    = "day"
+)
+
+var quotaWindows = []string{QuotaMinute, QuotaHour, QuotaDay}
+
+type QuotaWindow struct {
+	Limit     int     `json:"limit"`
+	Used      int     `json:"used"`
+	Remaining int     `json:"remaining"`
+	Percent   float64 `json:"percent"`
+}
+
+// QuotaLimits is the quota of an action (e.g. "public", "private", "search") in each window.
+type QuotaLimits struct {
+	Minute *QuotaWindow `json:"minute,omitempty"`
+	Hour   *QuotaWindow `json:"hour,omitempty"`
+	Day    *QuotaWindow `json:"day,omitempty"`
+}
+
+func (l QuotaLimits) window(name string) *QuotaWindow {
+	switch name {
+	case QuotaMinute:
+		return l.Minute
+	case QuotaHour:
+		return l.Hour
+	case QuotaDay:
+		return l.Day
+	default:
+		return nil
+	}
+}
+
+type Quotas struct {
+	Scope  string                 `json:"scope"`
+	Limits map[string]QuotaLimits `json:"limits"`
+	Raw    json.RawMessage        `json:"-"`
+}
+
+func (q *Quotas) UnmarshalJSON(data []byte) error {
+	type quotas Quotas
+	var dst quotas
+
+	err := json.Unmarshal(data, &dst)
+	if err != nil {
+		return err
+	}
+	*q = Quotas(dst)
+	q.Raw = data
+	return err
+}
+
+func (c *Client) GetQuotas() (*Quotas, error) {
+	return c.GetQuotasContext(context.Background())
+}
+
+func (c *Client) GetQuotasContext(ctx context.Context) (*Quotas, error) {
+	req := c.NewRequest().SetContext(ctx).SetPath(PrefixedPath("/quotas")).SetMethod("GET")
+	resp, err := req.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var q Quotas
+	err = resp.Unmarshal(&q)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// QuotaPolicy decides what a bulk operation does when it exceeds the remaining quota.
+type QuotaPolicy string
+
+const (
+	// QuotaPolicyWait sends all requests and holds them until the quota resets when it's used up
+	// (see Client.WaitForQuota).
+	QuotaPolicyWait QuotaPolicy = "wait"
+	// QuotaPolicyTruncate only sends the requests which fit in the remaining quota.
+	QuotaPolicyTruncate QuotaPolicy = "truncate"
+	// QuotaPolicyAbort fails without sending any request.
+	QuotaPolicyAbort QuotaPolicy = "abort"
+)
+
+func ParseQuotaPolicy(s string) (QuotaPolicy, error) {
+	switch policy := QuotaPolicy(s); policy {
+	case QuotaPolicyWait, QuotaPolicyTruncate, QuotaPolicyAbort:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid quota policy %q: must be one of wait, truncate, abort", s)
+	}
+}
+
+type QuotaPlanWindow struct {
+	Window    string `json:"window"`
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	// ResetAt is an estimate assuming the window is aligned to the wall clock in UTC.
+	ResetAt time.Time `json:"resetAt"`
+}
+
+// QuotaPlan reports how many of the requested requests for an action can go out
+// within the remaining quota of the current minute/hour/day windows.
+type QuotaPlan struct {
+	Action    string `json:"action"`
+	Requested int    `json:"requested"`
+	// Allowed is the number of requests which fit in all of the windows.
+	Allowed int               `json:"allowed"`
+	Windows []QuotaPlanWindow `json:"windows"`
+	// LimitingWindow is the window with the least remaining quota. It's empty if the action has no quota.
+	LimitingWindow string    `json:"limitingWindow,omitempty"`
+	ResetAt        time.Time `json:"resetAt,omitzero"`
+}
+
+// Exceeded reports whether the requested requests exceed the remaining quota.
+func (p *QuotaPlan) Exceeded() bool {
+	return p.Allowed < p.Requested
+}
+
+// Apply returns the number of requests to send under the policy. It returns an error
+// wrapping ErrQuotaExceeded if the policy is abort and the plan exceeds the quota.
+func (p *QuotaPlan) Apply(policy QuotaPolicy) (int, error) {
+	if !p.Exceeded() {
+		return p.Requested, nil
+	}
+
+	switch policy {
+	case QuotaPolicyTruncate:
+		return p.Allowed, nil
+	case QuotaPolicyAbort:
+		return 0, fmt.Errorf("%w: %d %s requests are planned but only %d remain in the current %s window (resets at %s)",
+			ErrQuotaExceeded, p.Requested, p.Action, p.Allowed, p.LimitingWindow, p.ResetAt.Format(time.RFC3339))
+	default:
+		return p.Requested, nil
+	}
+}
+
+func windowResetAt(window string, now time.Time) time.Time {
+	now = now.UTC()
+	switch window {
+	case QuotaMinute:
+		return now.Truncate(time.Minute).Add(time.Minute)
+	case QuotaHour:
+		return now.Truncate(time.Hour).Add(time.Hour)
+	default:
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Plan returns the plan to send n requests of the action. The action is the visibility
+// (public, unlisted or private) for scans, or the rate limit action (e.g. "search") for others.
+func (q *Quotas) Plan(action string, n int) *QuotaPlan {
+	return q.planAt(action, n, time.Now())
+}
+
+func (q *Quotas) planAt(action string, n int, now time.Time) *QuotaPlan {
+	plan := &QuotaPlan{
+		Action:         action,
+		Requested:      n,
+		Allowed:        n,
+		Windows:        []QuotaPlanWindow{},
+		LimitingWindow: "",
+		ResetAt:        time.Time{},
+	}
+
+	limits, ok := q.Limits[action]
+	if !ok {
+		return plan
+	}
+
+	least := -1
+	for _, name := range quotaWindows {
+		w := limits.window(name)
+		if w == nil {
+			continue
+		}
+		remaining := max(w.Remaining, 0)
+		resetAt := windowResetAt(name, now)
+		plan.Windows = append(plan.Windows, QuotaPlanWindow{
+			Window:    name,
+			Limit:     w.Limit,
+			Remaining: remaining,
+			ResetAt:   resetAt,
+		})
+		// prefer the longer window on a tie since it takes longer to reset
+		if least < 0 || remaining <= least {
+			least = remaining
+			plan.LimitingWindow = name
+			plan.ResetAt = resetAt
+		}
+	}
+	if least >= 0 {
+		plan.Allowed = min(n, least)
+	}
+	return plan
+}
+
+// quotaRequestAction returns the rate limit action of the requests of a quota action.
+// Scans are counted against the quota of their visibility.
+func quotaRequestAction(action string) string {
+	switch action {
+	case "public", "unlisted", "private":
+		return "scan"
+	default:
+		return action
+	}
+}
+
+// quotaWaiter holds the requests of an action once the remaining quota is used up.
+type quotaWaiter struct {
+	client *Client
+	action string
+	// sem serializes the requests of the action while the quota is checked (and waited for)
+	sem       chan struct{}
+	remaining int
+	resetAt   time.Time
+}
+
+// take takes a request from the remaining quota. If the quota is used up, it waits
+// until the quota resets and gets the remaining quota again.
+func (w *quotaWaiter) take(ctx context.Context) error {
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-w.sem }()
+
+	for w.remaining <= 0 {
+		delay := time.Until(w.resetAt)
+		w.client.Logger().Info(fmt.Sprintf("The quota is used up, waiting for %s", delay.Round(time.Second)),
+			"action", w.action, "resetAt", w.resetAt.Format(time.RFC3339))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		quotas, err := w.client.GetQuotasContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// leave it to the retry stage
+			w.client.Logger().Warn("Failed to get quotas, not waiting for the quota anymore", "error", err.Error())
+			w.remaining = math.MaxInt
+			break
+		}
+		plan := quotas.Plan(w.action, math.MaxInt)
+		w.remaining, w.resetAt = plan.Allowed, plan.ResetAt
+	}
+
+	w.remaining--
+	return nil
+}
+
+// WaitForQuota holds the requests of the quota action (see Quotas.Plan) once remaining
+// requests are sent, until the quota resets at resetAt. Then it gets the remaining quota
+// from the quotas endpoint and waits again when it's used up.
+func (c *Client) WaitForQuota(action string, remaining int, resetAt time.Time) *Client {
+	w := &quotaWaiter{
+		client:    c,
+		action:    action,
+		sem:       make(chan struct{}, 1),
+		remaining: remaining,
+		resetAt:   resetAt,
+	}
+	requestAction := quotaRequestAction(action)
+	return c.Use(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if rateLimitAction(apiPath(req)) == requestAction {
+				err := w.take(req.Context())
+				if err != nil {
+					return nil, err
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	})
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetQuotas(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/quotas").
+		Reply(http.StatusOK).
+		BodyString(`{"scope":"team","limits":{"public":{"minute":{"limit":60,"used":10,"remaining":50,"percent":16.67},"hour":{"limit":500,"used":10,"remaining":490,"percent":2},"day":{"limit":5000,"used":10,"remaining":4990,"percent":0.2}}}}`)
+
+	c := newTestClient()
+	q, err := c.GetQuotas()
+	assert.NoError(t, err)
+	assert.Equal(t, "team", q.Scope)
+	assert.Equal(t, 50, q.Limits["public"].Minute.Remaining)
+	assert.Equal(t, 5000, q.Limits["public"].Day.Limit)
+	assert.NotEmpty(t, q.Raw)
+	assert.Equal(t, gock.IsDone(), true)
+}
+
+func TestQuotaPlan(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	q := &Quotas{ //nolint:exhaustruct
+		Limits: map[string]QuotaLimits{
+			"public": {
+				Minute: &QuotaWindow{Limit: 60, Used: 0, Remaining: 60, Percent: 0},
+				Hour:   &QuotaWindow{Limit: 100, Used: 70, Remaining: 30, Percent: 70},
+				Day:    &QuotaWindow{Limit: 1000, Used: 900, Remaining: 100, Percent: 90},
+			},
+		},
+	}
+
+	t.Run("fits in the quota", func(t *testing.T) {
+		plan := q.planAt("public", 10, now)
+		assert.False(t, plan.Exceeded())
+		assert.Equal(t, 10, plan.Allowed)
+		assert.Equal(t, QuotaHour, plan.LimitingWindow)
+		assert.Len(t, plan.Windows, 3)
+
+		n, err := plan.Apply(QuotaPolicyAbort)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, n)
+	})
+
+	t.Run("exceeds the quota", func(t *testing.T) {
+		plan := q.planAt("public", 50, now)
+		assert.True(t, plan.Exceeded())
+		assert.Equal(t, 30, plan.Allowed)
+		assert.Equal(t, QuotaHour, plan.LimitingWindow)
+		assert.Equal(t, time.Date(2026, 1, 2, 4, 0, 0, 0, time.UTC), plan.ResetAt)
+
+		n, err := plan.Apply(QuotaPolicyWait)
+		assert.NoError(t, err)
+		assert.Equal(t, 50, n)
+
+		n, err = plan.Apply(QuotaPolicyTruncate)
+		assert.NoError(t, err)
+		assert.Equal(t, 30, n)
+
+		_, err = plan.Apply(QuotaPolicyAbort)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+	})
+
+	t.Run("no quota for the action", func(t *testing.T) {
+		plan := q.planAt("private", 50, now)
+		assert.False(t, plan.Exceeded())
+		assert.Equal(t, 50, plan.Allowed)
+		assert.Empty(t, plan.LimitingWindow)
+	})
+}
+
+func TestParseQuotaPolicy(t *testing.T) {
+	policy, err := ParseQuotaPolicy("truncate")
+	assert.NoError(t, err)
+	assert.Equal(t, QuotaPolicyTruncate, policy)
+
+	_, err = ParseQuotaPolicy("ignore")
+	assert.Error(t, err)
+}
+
+func TestWaitForQuota(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search").
+		Times(3).
+		Reply(http.StatusOK).
+		JSON(map[string]any{"results": []any{}, "total": 0})
+	gock.New("http://testserver/").
+		Get("/api/v1/quotas").
+		Times(1).
+		Reply(http.StatusOK).
+		BodyString(`{"scope":"team","limits":{"search":{"minute":{"limit":60,"used":55,"remaining":5,"percent":91.67}}}}`)
+
+	resetAt := time.Now().Add(200 * time.Millisecond)
+	c := newTestClient().SetRateLimiter(nil).WaitForQuota("search", 1, resetAt)
+
+	_, err := c.NewRequest().Get("/api/v1/search")
+	assert.NoError(t, err)
+	assert.True(t, time.Now().Before(resetAt))
+
+	// the quota is used up, so it's held until the reset and the quota is got again
+	_, err = c.NewRequest().Get("/api/v1/search")
+	assert.NoError(t, err)
+	assert.False(t, time.Now().Before(resetAt))
+	_, err = c.NewRequest().Get("/api/v1/search")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	t.Run("other actions are not held", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://testserver/").
+			Get("/api/v1/result/dummy/").
+			Reply(http.StatusOK).
+			JSON(map[string]any{})
+
+		c := newTestClient().WaitForQuota("public", 0, time.Now().Add(time.Hour))
+		_, err := c.NewRequest().Get("/api/v1/result/dummy/")
+		assert.NoError(t, err)
+	})
+
+	t.Run("wait respects context", func(t *testing.T) {
+		c := newTestClient().WaitForQuota("public", 0, time.Now().Add(time.Hour))
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := c.NewRequest().SetContext(ctx).SetBodyJSONBytes([]byte(`{"url":"https://example.com"}`)).Post("/api/v1/scan/")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var rateLimitWindows = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	// rate is the number of tokens refilled per second
+	rate float64
+	last time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// RateLimiter is a client-side token bucket rate limiter. Buckets are learned from the
+// X-Rate-Limit-* headers of responses and keyed by the scope and the action of the headers,
+// so the requests of different paths sharing a rate limit (e.g. results and screenshots)
+// share a bucket. A bucket starts pacing requests only after the first response with the
+// headers is received, and until then the requests are bucketed by the action of the path.
+type RateLimiter struct {
+	// Logger is the logger for pacing messages. The package default logger is used if it's nil.
+	Logger  *slog.Logger
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// routes maps the bucket of a path to the bucket learned from the response headers
+	routes map[string]string
+	now    func() time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		Logger:  nil,
+		mu:      sync.Mutex{},
+		buckets: make(map[string]*tokenBucket),
+		routes:  make(map[string]string),
+		now:     time.Now,
+	}
+}
+
+// rateLimitAction returns the rate limit action of a request path,
+// e.g. "/api/v1/search/" -> "search".
+func rateLimitAction(path string) string {
+	path = strings.TrimPrefix(path, apiPrefix)
+	path = strings.TrimPrefix(path, "/")
+	action, _, _ := strings.Cut(path, "/")
+	return action
+}
+
+// route returns the bucket learned for the bucket of a path, or the bucket of the path
+// if no response with the rate limit headers has been received yet.
+func (l *RateLimiter) route(bucket string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	learned, ok := l.routes[bucket]
+	if ok {
+		return learned
+	}
+	return bucket
+}
+
+// reserve takes a token from the bucket and returns how long the caller has to
+// wait before sending the request. Tokens can go negative so concurrent callers
+// are queued up instead of waking up at the same moment.
+func (l *RateLimiter) reserve(bucket string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[bucket]
+	if !ok || b.rate <= 0 {
+		return 0
+	}
+
+	b.refill(l.now())
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release gives back a token taken by reserve for a request which was not sent.
+func (l *RateLimiter) release(bucket string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[bucket]
+	if !ok {
+		return
+	}
+	b.tokens = min(b.capacity, b.tokens+1)
+}
+
+// rateLimitBucket returns the bucket of a path. Requests sent with a key of
+// a KeyPool are bucketed per key since each key has its own rate limit.
+func rateLimitBucket(path, key string) string {
+	action := rateLimitAction(path)
+	if key != "" {
+		return action + "@" + keyFingerprint(key)
+	}
+	return action
+}
+
+// headerBucket returns the bucket of the rate limit headers. A rate limit of the ip scope
+// is shared by all keys, so it's not bucketed per key.
+func headerBucket(header http.Header, key string) (string, bool) {
+	// rate limit headers: https://urlscan.io/docs/api/#ratelimit
+	action := header.Get("X-Rate-Limit-Action")
+	if action == "" {
+		return "", false
+	}
+	scope := header.Get("X-Rate-Limit-Scope")
+	bucket := scope + ":" + action
+	if key != "" && scope != "ip" {
+		bucket += "@" + keyFingerprint(key)
+	}
+	return bucket, true
+}
+
+// Wait blocks until a request for the path is allowed or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, path string) error {
+	return l.wait(ctx, rateLimitBucket(path, ""))
+}
+
+func (l *RateLimiter) wait(ctx context.Context, bucket string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	bucket = l.route(bucket)
+	delay := l.reserve(bucket)
+	if delay <= 0 {
+		return nil
+	}
+	requestStatsFromContext(ctx).addRateLimitSleep(delay)
+
+	loggerOrDefault(l.Logger).Debug(fmt.Sprintf("Rate limiter is pacing a request for %s", delay), "bucket", bucket)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// the request is not sent, so the token is not used
+		l.release(bucket)
+		return ctx.Err()
+	}
+}
+
+// Update learns the rate limit of the path from the response headers.
+func (l *RateLimiter) Update(path string, header http.Header) {
+	l.update(rateLimitBucket(path, ""), "", header)
+}
+
+// update learns the rate limit from the response headers of a request of the bucket,
+// sent with the key of a KeyPool (if any).
+func (l *RateLimiter) update(bucket, key string, header http.Header) {
+	// rate limit headers: https://urlscan.io/docs/api/#ratelimit
+	limit, err := strconv.Atoi(header.Get("X-Rate-Limit-Limit"))
+	if err != nil || limit <= 0 {
+		return
+	}
+	window, ok := rateLimitWindows[header.Get("X-Rate-Limit-Window")]
+	if !ok {
+		return
+	}
+
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	learned, ok := headerBucket(header, key)
+	if ok {
+		l.routes[bucket] = learned
+		bucket = learned
+	}
+
+	b, ok := l.buckets[bucket]
+	if !ok {
+		b = &tokenBucket{
+			capacity: float64(limit),
+			tokens:   float64(limit),
+			rate:     0,
+			last:     now,
+		}
+		l.buckets[bucket] = b
+	}
+	b.refill(now)
+
+	b.capacity = float64(limit)
+	b.rate = float64(limit) / window.Seconds()
+
+	// sync with the server side state
+	remaining, err := strconv.Atoi(header.Get("X-Rate-Limit-Remaining"))
+	if err == nil {
+		b.tokens = min(b.tokens, float64(remaining))
+	}
+}
+
+type RateLimitTransport struct {
+	Transport http.RoundTripper
+	Limiter   *RateLimiter
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Limiter == nil {
+		return t.Transport.RoundTrip(req)
+	}
+
+	key, _ := req.Context().Value(keyPoolContextKey{}).(string)
+	bucket := rateLimitBucket(apiPath(req), key)
+	err := t.Limiter.wait(req.Context(), bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+	if err == nil {
+		t.Limiter.update(bucket, key, res.Header)
+	}
+	return res, err
+}
+
+func (t *RateLimitTransport) setLogger(logger *slog.Logger) {
+	if t.Limiter != nil {
+		t.Limiter.Logger = logger
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRateLimitHeader(limit, remaining, window string) http.Header {
+	header := make(http.Header)
+	header.Set("X-Rate-Limit-Limit", limit)
+	header.Set("X-Rate-Limit-Remaining", remaining)
+	header.Set("X-Rate-Limit-Window", window)
+	header.Set("X-Rate-Limit-Scope", "team")
+	return header
+}
+
+func TestRateLimitAction(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/api/v1/search/", expected: "search"},
+		{path: "/api/v1/search", expected: "search"},
+		{path: "/api/v1/scan/", expected: "scan"},
+		{path: "/api/v1/result/dummy/", expected: "result"},
+		{path: "/dom/dummy/", expected: "dom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rateLimitAction(tt.path))
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("does not wait for unknown actions", func(t *testing.T) {
+		l := NewRateLimiter()
+		for range 100 {
+			assert.NoError(t, l.Wait(t.Context(), "/api/v1/search/"))
+		}
+	})
+
+	t.Run("paces requests after learning the limit", func(t *testing.T) {
+		now := time.Now()
+		l := NewRateLimiter()
+		l.now = func() time.Time { return now }
+
+		l.Update("/api/v1/search/", newRateLimitHeader("60", "2", "minute"))
+		assert.Equal(t, time.Duration(0), l.reserve("search"))
+		assert.Equal(t, time.Duration(0), l.reserve("search"))
+		// 1 token per second
+		assert.Equal(t, 1*time.Second, l.reserve("search"))
+		// queued up behind the previous reservation
+		assert.Equal(t, 2*time.Second, l.reserve("search"))
+
+		// other actions are not affected
+		assert.Equal(t, time.Duration(0), l.reserve("scan"))
+
+		// refilled after some time
+		now = now.Add(10 * time.Second)
+		assert.Equal(t, time.Duration(0), l.reserve("search"))
+	})
+
+	t.Run("wait respects context", func(t *testing.T) {
+		l := NewRateLimiter()
+		l.Update("/api/v1/scan/", newRateLimitHeader("1", "0", "hour"))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+
+		err := l.Wait(ctx, "/api/v1/scan/")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("gives back the token of a canceled request", func(t *testing.T) {
+		now := time.Now()
+		l := NewRateLimiter()
+		l.now = func() time.Time { return now }
+		l.Update("/api/v1/scan/", newRateLimitHeader("1", "0", "hour"))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		err := l.Wait(ctx, "/api/v1/scan/")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// queued up as if the canceled request had not been made
+		assert.Equal(t, time.Hour, l.reserve("scan"))
+
+		// a request of a canceled context doesn't take a token
+		ctx, cancel = context.WithCancel(t.Context())
+		cancel()
+		assert.ErrorIs(t, l.Wait(ctx, "/api/v1/scan/"), context.Canceled)
+		assert.Equal(t, 2*time.Hour, l.reserve("scan"))
+	})
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter()
+	l.now = func() time.Time { return now }
+
+	// bucketed by the path before the first response
+	l.update("result", "", http.Header{
+		"X-Rate-Limit-Limit":     {"60"},
+		"X-Rate-Limit-Remaining": {"1"},
+		"X-Rate-Limit-Window":    {"minute"},
+	})
+	assert.Equal(t, "result", l.route("result"))
+	assert.Contains(t, l.buckets, "result")
+
+	// the paths sharing a rate limit share the bucket of the headers
+	header := newRateLimitHeader("60", "1", "minute")
+	header.Set("X-Rate-Limit-Action", "retrieve")
+	l.update("result", "", header)
+	l.update("screenshots", "", header)
+	assert.Equal(t, "team:retrieve", l.route("result"))
+	assert.Equal(t, "team:retrieve", l.route("screenshots"))
+	assert.Equal(t, time.Duration(0), l.reserve(l.route("screenshots")))
+	assert.Equal(t, time.Second, l.reserve(l.route("result")))
+
+	// the rate limit of the ip scope is shared by the keys of a key pool
+	header.Set("X-Rate-Limit-Scope", "ip")
+	l.update(rateLimitBucket("/api/v1/search/", "a"), "a", header)
+	l.update(rateLimitBucket("/api/v1/search/", "b"), "b", header)
+	assert.Equal(t, "ip:retrieve", l.route(rateLimitBucket("/api/v1/search/", "a")))
+	assert.Equal(t, "ip:retrieve", l.route(rateLimitBucket("/api/v1/search/", "b")))
+	header.Set("X-Rate-Limit-Scope", "team")
+	l.update(rateLimitBucket("/api/v1/search/", "a"), "a", header)
+	assert.Equal(t, "team:retrieve@"+keyFingerprint("a"), l.route(rateLimitBucket("/api/v1/search/", "a")))
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/api/v1/search/").
+		Reply(http.StatusOK).
+		SetHeaders(map[string]string{
+			"X-Rate-Limit-Limit":     "120",
+			"X-Rate-Limit-Remaining": "100",
+			"X-Rate-Limit-Window":    "minute",
+			"X-Rate-Limit-Scope":     "team",
+			"X-Rate-Limit-Action":    "search",
+		}).
+		JSON(map[string]any{"results": []any{}})
+
+	limiter := NewRateLimiter()
+	c := newTestClient().SetRateLimiter(limiter)
+	_, err := c.NewRequest().Get("/api/v1/search/")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	b, ok := limiter.buckets["team:search"]
+	assert.True(t, ok)
+	assert.Equal(t, "team:search", limiter.routes["search"])
+	assert.Equal(t, float64(120), b.capacity)
+	assert.Equal(t, float64(2), b.rate)
+	assert.Equal(t, float64(100), b.tokens)
+}
@@ -19,6 +19,7 @@ type Request struct {
 	client      *Client
 	ctx         context.Context
 	RawRequest  *http.Request
+	Stream      bool
 }
 
 func (r *Request) SetPath(path string) *Request {
@@ -61,6 +62,18 @@ func (r *Request) SetQueryParam(key, value string) *Request {
 	return r
 }
 
+func (r *Request) DelQueryParam(key string) *Request {
+	delete(r.QueryParams, key)
+	return r
+}
+
+// SetStream keeps the live response body instead of buffering it in memory.
+// The caller is responsible for closing Response.Body. Error responses are always buffered.
+func (r *Request) SetStream(stream bool) *Request {
+	r.Stream = stream
+	return r
+}
+
 func (r *Request) SetContext(ctx context.Context) *Request {
 	r.ctx = ctx
 	return r
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -65,8 +66,21 @@ func (r *Response) Error() error {
 
 	var jsonErr JSONError
 	err := json.Unmarshal(r.body, &jsonErr)
-	if err != nil {
-		return err
+	if err != nil || jsonErr.Message == "" {
+		// non-JSON (or unexpected) error body
+		jsonErr = JSONError{
+			Status:      r.StatusCode,
+			Message:     http.StatusText(r.StatusCode),
+			Description: "",
+			Raw:         nil,
+			Header:      nil,
+		}
+	}
+	if jsonErr.Status == 0 {
+		jsonErr.Status = r.StatusCode
+	}
+	if r.Response != nil {
+		jsonErr.Header = r.Header
 	}
 	return jsonErr
 }
@@ -115,11 +129,18 @@ func (r *Response) ToJSON() (*json.RawMessage, error) {
 	return &raw, nil
 }
 
+func (r *Response) logger() *slog.Logger {
+	if r.Request == nil || r.Request.client == nil {
+		return DefaultLogger()
+	}
+	return r.Request.client.Logger()
+}
+
 func (r *Response) PrettyJSON() string {
 	var jsonBody bytes.Buffer
 	err := json.Indent(&jsonBody, r.body, "", "  ")
 	if err != nil {
-		log.Info("error formatting JSON response, fallback to the original", "error", err)
+		r.logger().Info("error formatting JSON response, fallback to the original", "error", err)
 		return string(r.body)
 	}
 	return jsonBody.String()
@@ -1,46 +1,356 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"time"
 )
 
+// Result is a scan result returned by /api/v1/result/{uuid}/.
+// Fields which have no stable shape are kept as json.RawMessage. The original JSON is kept in Raw.
+type Result struct {
+	Data      ResultData      `json:"data"`
+	Stats     ResultStats     `json:"stats"`
+	Meta      json.RawMessage `json:"meta"`
+	Task      ResultTask      `json:"task"`
+	Page      ResultPage      `json:"page"`
+	Lists     ResultLists     `json:"lists"`
+	Verdicts  ResultVerdicts  `json:"verdicts"`
+	Submitter ResultSubmitter `json:"submitter"`
+	Raw       json.RawMessage `json:"-"`
+}
+
+type ResultData struct {
+	Requests []ResultRequest `json:"requests"`
+	Cookies  []ResultCookie  `json:"cookies"`
+	Console  []ResultConsole `json:"console"`
+	Links    []ResultLink    `json:"links"`
+	Timing   json.RawMessage `json:"timing"`
+	Globals  []ResultGlobal  `json:"globals"`
+}
+
+type ResultRequest struct {
+	Request       ResultRequestInfo   `json:"request"`
+	Response      ResultResponseInfo  `json:"response"`
+	InitiatorInfo json.RawMessage     `json:"initiatorInfo,omitempty"`
+	Requests      []ResultRequestInfo `json:"requests,omitempty"`
+}
+
+type ResultRequestInfo struct {
+	RequestID            string          `json:"requestId"`
+	LoaderID             string          `json:"loaderId"`
+	DocumentURL          string          `json:"documentURL"`
+	Request              HTTPRequest     `json:"request"`
+	Timestamp            float64         `json:"timestamp"`
+	WallTime             float64         `json:"wallTime"`
+	Initiator            json.RawMessage `json:"initiator"`
+	RedirectHasExtraInfo bool            `json:"redirectHasExtraInfo"`
+	RedirectResponse     *HTTPResponse   `json:"redirectResponse,omitempty"`
+	Type                 string          `json:"type"`
+	FrameID              string          `json:"frameId"`
+	HasUserGesture       bool            `json:"hasUserGesture"`
+	PrimaryRequest       bool            `json:"primaryRequest"`
+}
+
+type HTTPRequest struct {
+	URL              string            `json:"url"`
+	Method           string            `json:"method"`
+	Headers          map[string]string `json:"headers"`
+	MixedContentType string            `json:"mixedContentType"`
+	InitialPriority  string            `json:"initialPriority"`
+	ReferrerPolicy   string            `json:"referrerPolicy"`
+	IsSameSite       bool              `json:"isSameSite"`
+}
+
+type ResultResponseInfo struct {
+	EncodedDataLength int             `json:"encodedDataLength"`
+	DataLength        int             `json:"dataLength"`
+	RequestID         string          `json:"requestId"`
+	Type              string          `json:"type"`
+	Response          HTTPResponse    `json:"response"`
+	Hash              string          `json:"hash"`
+	Size              int             `json:"size"`
+	ASN               *ResultASN      `json:"asn,omitempty"`
+	GeoIP             *ResultGeoIP    `json:"geoip,omitempty"`
+	RDNS              json.RawMessage `json:"rdns,omitempty"`
+}
+
+type HTTPResponse struct {
+	URL               string            `json:"url"`
+	Status            int               `json:"status"`
+	StatusText        string            `json:"statusText"`
+	Headers           map[string]string `json:"headers"`
+	MimeType          string            `json:"mimeType"`
+	RemoteIPAddress   string            `json:"remoteIPAddress"`
+	RemotePort        int               `json:"remotePort"`
+	EncodedDataLength int               `json:"encodedDataLength"`
+	Protocol          string            `json:"protocol"`
+	SecurityState     string            `json:"securityState"`
+	SecurityDetails   *SecurityDetails  `json:"securityDetails,omitempty"`
+}
+
+type SecurityDetails struct {
+	Protocol      string   `json:"protocol"`
+	KeyExchange   string   `json:"keyExchange"`
+	Cipher        string   `json:"cipher"`
+	CertificateID int      `json:"certificateId"`
+	SubjectName   string   `json:"subjectName"`
+	SanList       []string `json:"sanList"`
+	Issuer        string   `json:"issuer"`
+	ValidFrom     int64    `json:"validFrom"`
+	ValidTo       int64    `json:"validTo"`
+}
+
+type ResultASN struct {
+	IP          string `json:"ip"`
+	ASN         string `json:"asn"`
+	Country     string `json:"country"`
+	Registrar   string `json:"registrar"`
+	Date        string `json:"date"`
+	Description string `json:"description"`
+	Route       string `json:"route"`
+	Name        string `json:"name"`
+}
+
+type ResultGeoIP struct {
+	Country     string    `json:"country"`
+	Region      string    `json:"region"`
+	Timezone    string    `json:"timezone"`
+	City        string    `json:"city"`
+	LL          []float64 `json:"ll"`
+	CountryName string    `json:"country_name"`
+	Metro       int       `json:"metro"`
+	Area        int       `json:"area"`
+}
+
+type ResultCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	Size     int     `json:"size"`
+	HTTPOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+	Session  bool    `json:"session"`
+	SameSite string  `json:"sameSite,omitempty"`
+}
+
+type ResultConsole struct {
+	Message struct {
+		Source    string  `json:"source"`
+		Level     string  `json:"level"`
+		Text      string  `json:"text"`
+		Timestamp float64 `json:"timestamp"`
+		URL       string  `json:"url"`
+	} `json:"message"`
+}
+
+type ResultLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+type ResultGlobal struct {
+	Prop string `json:"prop"`
+	Type string `json:"type"`
+}
+
+type ResultStats struct {
+	ResourceStats    []json.RawMessage `json:"resourceStats"`
+	ProtocolStats    []json.RawMessage `json:"protocolStats"`
+	TLSStats         []json.RawMessage `json:"tlsStats"`
+	ServerStats      []json.RawMessage `json:"serverStats"`
+	DomainStats      []json.RawMessage `json:"domainStats"`
+	RegDomainStats   []json.RawMessage `json:"regDomainStats"`
+	IPStats          []json.RawMessage `json:"ipStats"`
+	SecureRequests   int               `json:"secureRequests"`
+	SecurePercentage int               `json:"securePercentage"`
+	IPv6Percentage   int               `json:"IPv6Percentage"`
+	UniqCountries    int               `json:"uniqCountries"`
+	TotalLinks       int               `json:"totalLinks"`
+	Malicious        int               `json:"malicious"`
+	AdBlocked        int               `json:"adBlocked"`
+}
+
+type ResultTask struct {
+	UUID          string   `json:"uuid"`
+	Time          string   `json:"time"`
+	URL           string   `json:"url"`
+	Visibility    string   `json:"visibility"`
+	Method        string   `json:"method"`
+	Source        string   `json:"source"`
+	Tags          []string `json:"tags"`
+	ReportURL     string   `json:"reportURL"`
+	ScreenshotURL string   `json:"screenshotURL"`
+	DomURL        string   `json:"domURL"`
+	Domain        string   `json:"domain"`
+	ApexDomain    string   `json:"apexDomain"`
+	UserAgent     string   `json:"userAgent,omitempty"`
+}
+
+type ResultPage struct {
+	URL          string      `json:"url"`
+	Domain       string      `json:"domain"`
+	ApexDomain   string      `json:"apexDomain"`
+	Title        string      `json:"title"`
+	Country      string      `json:"country"`
+	City         string      `json:"city"`
+	Server       string      `json:"server"`
+	IP           string      `json:"ip"`
+	PTR          string      `json:"ptr,omitempty"`
+	ASN          string      `json:"asn"`
+	ASNName      string      `json:"asnname"`
+	Status       json.Number `json:"status"`
+	MimeType     string      `json:"mimeType"`
+	Redirected   string      `json:"redirected,omitempty"`
+	UmbrellaRank int         `json:"umbrellaRank,omitempty"`
+	TLSIssuer    string      `json:"tlsIssuer,omitempty"`
+	TLSValidDays int         `json:"tlsValidDays,omitempty"`
+	TLSAgeDays   int         `json:"tlsAgeDays,omitempty"`
+	TLSValidFrom string      `json:"tlsValidFrom,omitempty"`
+}
+
+type ResultLists struct {
+	IPs          []string            `json:"ips"`
+	Countries    []string            `json:"countries"`
+	ASNs         []string            `json:"asns"`
+	Domains      []string            `json:"domains"`
+	Servers      []string            `json:"servers"`
+	URLs         []string            `json:"urls"`
+	LinkDomains  []string            `json:"linkDomains"`
+	Certificates []ResultCertificate `json:"certificates"`
+	Hashes       []string            `json:"hashes"`
+}
+
+type ResultCertificate struct {
+	SubjectName string `json:"subjectName"`
+	Issuer      string `json:"issuer"`
+	ValidFrom   int64  `json:"validFrom"`
+	ValidTo     int64  `json:"validTo"`
+}
+
+type ResultVerdicts struct {
+	Overall   Verdict          `json:"overall"`
+	URLScan   Verdict          `json:"urlscan"`
+	Engines   EnginesVerdict   `json:"engines"`
+	Community CommunityVerdict `json:"community"`
+}
+
+type Verdict struct {
+	Score       int               `json:"score"`
+	Categories  []string          `json:"categories"`
+	Brands      []json.RawMessage `json:"brands"`
+	Tags        []string          `json:"tags,omitempty"`
+	Malicious   bool              `json:"malicious"`
+	HasVerdicts bool              `json:"hasVerdicts"`
+}
+
+type EnginesVerdict struct {
+	Score          int               `json:"score"`
+	Categories     []string          `json:"categories"`
+	Malicious      []json.RawMessage `json:"malicious"`
+	Benign         []json.RawMessage `json:"benign"`
+	MaliciousTotal int               `json:"maliciousTotal"`
+	BenignTotal    int               `json:"benignTotal"`
+	Verdicts       []json.RawMessage `json:"verdicts"`
+	EnginesTotal   int               `json:"enginesTotal"`
+}
+
+type CommunityVerdict struct {
+	Score          int               `json:"score"`
+	Categories     []string          `json:"categories"`
+	Brands         []json.RawMessage `json:"brands"`
+	Votes          []json.RawMessage `json:"votes"`
+	VotesTotal     int               `json:"votesTotal"`
+	VotesMalicious int               `json:"votesMalicious"`
+	VotesBenign    int               `json:"votesBenign"`
+	Tags           []string          `json:"tags,omitempty"`
+	HasVerdicts    bool              `json:"hasVerdicts"`
+}
+
+type ResultSubmitter struct {
+	Country string `json:"country"`
+}
+
+func (r *Result) UnmarshalJSON(data []byte) error {
+	type result Result
+	var dst result
+
+	err := json.Unmarshal(data, &dst)
+	if err != nil {
+		return err
+	}
+	*r = Result(dst)
+	r.Raw = data
+	return err
+}
+
+func (r *Result) PrettyJSON() string {
+	var jsonBody bytes.Buffer
+	err := json.Indent(&jsonBody, r.Raw, "", "  ")
+	if err != nil {
+		msg := fmt.Sprintf("error formatting JSON response: %s", err)
+		panic(msg)
+	}
+	return jsonBody.String()
+}
+
 func (c *Client) GetResult(uuid string) (*Response, error) {
-	return c.NewRequest().Get(
+	return c.GetResultContext(context.Background(), uuid)
+}
+
+func (c *Client) GetResultContext(ctx context.Context, uuid string) (*Response, error) {
+	return c.NewRequest().SetContext(ctx).Get(
 		PrefixedPath(fmt.Sprintf("/result/%s/", uuid)),
 	)
 }
 
+func (c *Client) GetResultTyped(uuid string) (*Result, error) {
+	return c.GetResultTypedContext(context.Background(), uuid)
+}
+
+func (c *Client) GetResultTypedContext(ctx context.Context, uuid string) (*Result, error) {
+	resp, err := c.GetResultContext(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Result
+	err = resp.Unmarshal(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 func (c *Client) WaitAndGetResult(ctx context.Context, uuid string, maxWait int) (*Response, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(maxWait)*time.Second)
 	defer cancel()
 
-	log.Info("Waiting for scan to finish", "uuid", uuid)
+	c.Logger().Info("Waiting for scan to finish", "uuid", uuid)
 
 	delay := 1 * time.Second
 
 	for {
-		result, err := c.GetResult(uuid)
+		result, err := c.GetResultContext(ctx, uuid)
 		if err == nil {
 			return result, nil
 		}
 
 		// raise an error if it's not 404 error
-		jsonErr, ok := errors.AsType[*JSONError](err)
-		if ok {
-			if jsonErr.Status != http.StatusNotFound {
-				return nil, err
-			}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
 		}
 
 		select {
 		case <-time.After(delay):
 			delay += 1 * time.Second
-			log.Info("Got 404 error, waiting for a scan result...", "delay", delay, "error", err.Error(), "uuid", uuid)
+			c.Logger().Info("Got 404 error, waiting for a scan result...", "delay", delay, "error", err.Error(), "uuid", uuid)
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		}
@@ -62,6 +372,10 @@ func WithResultVisibility(visibility string) ResultVisibilityOption {
 }
 
 func (c *Client) UpdateResultVisibility(uuid string, opts ...ResultVisibilityOption) (*Response, error) {
+	return c.UpdateResultVisibilityContext(context.Background(), uuid, opts...)
+}
+
+func (c *Client) UpdateResultVisibilityContext(ctx context.Context, uuid string, opts ...ResultVisibilityOption) (*Response, error) {
 	var options ResultVisibilityOptions
 	for _, opt := range opts {
 		opt(&options)
@@ -72,7 +386,7 @@ func (c *Client) UpdateResultVisibility(uuid string, opts ...ResultVisibilityOpt
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Put(
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Put(
 		PrefixedPath(fmt.Sprintf("/result/%s/visibility/", uuid)),
 	)
 }
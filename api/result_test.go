@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetResultTyped(t *testing.T) {
+	defer gock.Off()
+
+	body, err := os.ReadFile("testdata/result.json")
+	assert.NoError(t, err)
+
+	gock.New("http://testserver/").
+		Get("/api/v1/result/0e37e828-a9d9-45c0-ac50-1ca579b86c72/").
+		Reply(http.StatusOK).
+		SetHeader("Content-Type", "application/json").
+		BodyString(string(body))
+
+	c := newTestClient()
+	got, err := c.GetResultTyped("0e37e828-a9d9-45c0-ac50-1ca579b86c72")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	// task
+	assert.Equal(t, "0e37e828-a9d9-45c0-ac50-1ca579b86c72", got.Task.UUID)
+	assert.Equal(t, "public", got.Task.Visibility)
+	assert.Equal(t, []string{"foo"}, got.Task.Tags)
+
+	// page
+	assert.Equal(t, "Example Domain", got.Page.Title)
+	assert.Equal(t, "AS15133", got.Page.ASN)
+	assert.Equal(t, json.Number("200"), got.Page.Status)
+	assert.Equal(t, 394, got.Page.TLSValidDays)
+
+	// lists
+	assert.Equal(t, []string{"example.com"}, got.Lists.Domains)
+	assert.Len(t, got.Lists.Certificates, 1)
+	assert.Equal(t, int64(1738367999), got.Lists.Certificates[0].ValidTo)
+
+	// verdicts
+	assert.False(t, got.Verdicts.Overall.Malicious)
+	assert.Equal(t, 0, got.Verdicts.Engines.EnginesTotal)
+
+	// stats
+	assert.Equal(t, 100, got.Stats.SecurePercentage)
+	assert.Len(t, got.Stats.ResourceStats, 1)
+
+	// data.requests
+	assert.Len(t, got.Data.Requests, 1)
+	req := got.Data.Requests[0]
+	assert.Equal(t, "GET", req.Request.Request.Method)
+	assert.Equal(t, 200, req.Response.Response.Status)
+	assert.Equal(t, "TLS 1.3", req.Response.Response.SecurityDetails.Protocol)
+	assert.Equal(t, "15133", req.Response.ASN.ASN)
+	assert.Equal(t, "US", req.Response.GeoIP.Country)
+
+	// raw JSON is preserved
+	assert.JSONEq(t, string(body), string(got.Raw))
+}
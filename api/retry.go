@@ -0,0 +1,243 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on every subsequent retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff delay.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0.0 - 1.0) of the delay that is randomized.
+	Jitter float64
+	// RetryableStatusCodes is the list of HTTP status codes which trigger a retry.
+	RetryableStatusCodes []int
+	// IsRetryableError decides whether a transport error triggers a retry.
+	IsRetryableError func(error) bool
+	// RetryNonIdempotent allows retrying non-idempotent requests (e.g. POST) on errors other than 429.
+	RetryNonIdempotent bool
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   1 * time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.5,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		IsRetryableError:   IsRetryableNetworkError,
+		RetryNonIdempotent: false,
+	}
+}
+
+// IsRetryableNetworkError reports whether err is a transient network error
+// such as a timeout, a connection reset or an unexpected EOF.
+func IsRetryableNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	netErr, ok := errors.AsType[net.Error](err)
+	if ok && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *RetryPolicy) isRetryableStatus(code int) bool {
+	return slices.Contains(p.RetryableStatusCodes, code)
+}
+
+func (p *RetryPolicy) isRetryableError(err error) bool {
+	if p.IsRetryableError == nil {
+		return false
+	}
+	return p.IsRetryableError(err)
+}
+
+// backoff returns the delay before the n-th retry (starting from 1).
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter > 0 && delay > 0 {
+		fixed := time.Duration(float64(delay) * (1 - jitter))
+		random := time.Duration(rand.Int64N(int64(float64(delay)*jitter) + 1))
+		delay = fixed + random
+	}
+	return delay
+}
+
+type RetryTransport struct {
+	Transport http.RoundTripper
+	// Policy is the retry policy. DefaultRetryPolicy is used if it's nil.
+	Policy *RetryPolicy
+	// Logger is the logger for retry messages. The package default logger is used if it's nil.
+	Logger *slog.Logger
+}
+
+func (t *RetryTransport) policy() *RetryPolicy {
+	if t.Policy == nil {
+		return DefaultRetryPolicy()
+	}
+	return t.Policy
+}
+
+// shouldRetry decides whether the request should be retried. 429 is always retryable
+// regardless of the method since the request was not processed by the server.
+func (t *RetryTransport) shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	policy := t.policy()
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if !policy.isRetryableError(err) {
+			return false
+		}
+	} else {
+		if !policy.isRetryableStatus(res.StatusCode) {
+			return false
+		}
+		if res.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+	}
+
+	return isIdempotent(req.Method) || policy.RetryNonIdempotent
+}
+
+// rateLimitDelay returns the delay based on the rate limit headers of a 429 response.
+func rateLimitDelay(logger *slog.Logger, res *http.Response) (time.Duration, bool) {
+	// rate limit headers: https://urlscan.io/docs/api/#ratelimit
+	retryAfter := res.Header.Get("X-Rate-Limit-Reset-After")
+	if retryAfter == "" {
+		return 0, false
+	}
+
+	retryAfterInt, err := strconv.Atoi(retryAfter)
+	if err != nil {
+		return 0, false
+	}
+
+	logger.Info(fmt.Sprintf("Sleeping for %s seconds", retryAfter),
+		"X-Rate-Limit-Action", res.Header.Get("X-Rate-Limit-Action"),
+		"X-Rate-Limit-Limit", res.Header.Get("X-Rate-Limit-Limit"),
+		"X-Rate-Limit-Reset-After", retryAfter,
+		"X-Rate-Limit-Reset", res.Header.Get("X-Rate-Limit-Reset"),
+		"X-Rate-Limit-Scope", res.Header.Get("X-Rate-Limit-Scope"),
+		"X-Rate-Limit-Window", res.Header.Get("X-Rate-Limit-Window"),
+	)
+	return time.Duration(retryAfterInt) * time.Second, true
+}
+
+func (t *RetryTransport) setLogger(logger *slog.Logger) {
+	t.Logger = logger
+}
+
+func drainBody(res *http.Response) {
+	if res == nil || res.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+	_ = res.Body.Close()
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := t.policy()
+	logger := loggerOrDefault(t.Logger)
+	maxAttempts := max(policy.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("cannot retry request: request body is not replayable")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		res, err := t.Transport.RoundTrip(attemptReq)
+		if attempt >= maxAttempts || !t.shouldRetry(req, res, err) {
+			return res, err
+		}
+
+		stats := requestStatsFromContext(req.Context())
+		stats.addRetry()
+
+		var delay time.Duration
+		if err == nil {
+			var ok bool
+			if res.StatusCode == http.StatusTooManyRequests {
+				delay, ok = rateLimitDelay(logger, res)
+				if ok {
+					stats.addRateLimitSleep(delay)
+				}
+			}
+			if !ok {
+				delay = policy.backoff(attempt)
+				logger.Info(fmt.Sprintf("Got HTTP %d, retrying in %s", res.StatusCode, delay),
+					"method", req.Method, "url", req.URL.String(), "attempt", attempt)
+			}
+			drainBody(res)
+		} else {
+			delay = policy.backoff(attempt)
+			logger.Info(fmt.Sprintf("Got a network error, retrying in %s", delay),
+				"method", req.Method, "url", req.URL.String(), "attempt", attempt, "error", err.Error())
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
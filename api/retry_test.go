@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = 0
+	policy.Jitter = 0
+	return policy
+}
+
+func TestRetryServerError(t *testing.T) {
+	defer gock.Off()
+
+	retryCounter := &Counter{count: 0}
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Times(2).
+		AddMatcher(func(req *http.Request, ereq *gock.Request) (bool, error) { return retryCounter.Count() < 2, nil }).
+		Reply(http.StatusBadGateway)
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	c := newTestClient().SetRetryPolicy(newTestRetryPolicy())
+	got, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"foo\":\"bar\"}\n", string(got.body))
+	assert.True(t, gock.IsDone())
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Times(2).
+		Reply(http.StatusServiceUnavailable).
+		JSON(map[string]any{"status": 503, "message": "Service Unavailable"})
+
+	policy := newTestRetryPolicy()
+	policy.MaxAttempts = 2
+
+	c := newTestClient().SetRetryPolicy(policy)
+	_, err := c.NewRequest().Get("/bar")
+	assert.Error(t, err)
+	assert.True(t, gock.IsDone())
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	t.Run("POST is not retried by default", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://testserver/").
+			Post("/bar").
+			Reply(http.StatusBadGateway).
+			JSON(map[string]any{"status": 502, "message": "Bad Gateway"})
+
+		c := newTestClient().SetRetryPolicy(newTestRetryPolicy())
+		_, err := c.NewRequest().SetBodyJSONBytes([]byte(`{"foo":"bar"}`)).Post("/bar")
+		assert.Error(t, err)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("POST is retried with the same body when opted in", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://testserver/").
+			Post("/bar").
+			JSON(map[string]string{"foo": "bar"}).
+			Reply(http.StatusBadGateway)
+
+		gock.New("http://testserver/").
+			Post("/bar").
+			JSON(map[string]string{"foo": "bar"}).
+			Reply(http.StatusOK).
+			JSON(map[string]string{"bar": "baz"})
+
+		policy := newTestRetryPolicy()
+		policy.RetryNonIdempotent = true
+
+		c := newTestClient().SetRetryPolicy(policy)
+		got, err := c.NewRequest().SetBodyJSONBytes([]byte(`{"foo":"bar"}`)).Post("/bar")
+		assert.NoError(t, err)
+		assert.Equal(t, "{\"bar\":\"baz\"}\n", string(got.body))
+		assert.True(t, gock.IsDone())
+	})
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		ReplyError(fmt.Errorf("connection reset: %w", syscall.ECONNRESET))
+
+	gock.New("http://testserver/").
+		Get("/bar").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	c := newTestClient().SetRetryPolicy(newTestRetryPolicy())
+	got, err := c.NewRequest().Get("/bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"foo\":\"bar\"}\n", string(got.body))
+	assert.True(t, gock.IsDone())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = 1 * time.Second
+	policy.MaxDelay = 5 * time.Second
+	policy.Jitter = 0
+
+	assert.Equal(t, 1*time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 4*time.Second, policy.backoff(3))
+	assert.Equal(t, 5*time.Second, policy.backoff(4))
+
+	policy.Jitter = 0.5
+	for range 10 {
+		delay := policy.backoff(2)
+		assert.GreaterOrEqual(t, delay, 1*time.Second)
+		assert.LessOrEqual(t, delay, 2*time.Second)
+	}
+}
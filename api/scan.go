@@ -128,7 +128,11 @@ func (c *Client) NewScanRequest(url string, opts ...ScanOption) *Request {
 }
 
 func (c *Client) Scan(url string, options ...ScanOption) (*ScanResult, error) {
-	req := c.NewScanRequest(url, options...)
+	return c.ScanContext(context.Background(), url, options...)
+}
+
+func (c *Client) ScanContext(ctx context.Context, url string, options ...ScanOption) (*ScanResult, error) {
+	req := c.NewScanRequest(url, options...).SetContext(ctx)
 	resp, err := req.Do()
 	if err != nil {
 		return nil, err
@@ -145,7 +149,7 @@ func (c *Client) Scan(url string, options ...ScanOption) (*ScanResult, error) {
 
 func (c *Client) NewBatchScanTask(url string, opts ...ScanOption) BatchTask[*Response] {
 	return func(c *Client, ctx context.Context) mo.Result[*Response] {
-		req := c.NewScanRequest(url, opts...)
+		req := c.NewScanRequest(url, opts...).SetContext(ctx)
 		resp, err := req.Do()
 		if err != nil {
 			return mo.Err[*Response](err)
@@ -156,7 +160,7 @@ func (c *Client) NewBatchScanTask(url string, opts ...ScanOption) BatchTask[*Res
 
 func (c *Client) NewBatchScanWithWaitTask(url string, maxWait int, opts ...ScanOption) BatchTask[*Response] {
 	return func(c *Client, ctx context.Context) mo.Result[*Response] {
-		scanReq := c.NewScanRequest(url, opts...)
+		scanReq := c.NewScanRequest(url, opts...).SetContext(ctx)
 		scanResp, err := scanReq.Do()
 		if err != nil {
 			return mo.Err[*Response](err)
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -90,23 +91,36 @@ func newSavedSearchOptions(opts ...SavedSearchOption) *SavedSearchOptions {
 	return &o
 }
 
+// ListSavedSearches returns an iterator over the saved searches of the current user.
+func (c *Client) ListSavedSearches(opts ...IteratorOption) (*PageIterator[json.RawMessage], error) {
+	return newIterator(c, PrefixedPath("/user/searches/"), Paginator[json.RawMessage](ListPaginator[json.RawMessage]{Field: "searches"}), opts...)
+}
+
 func (c *Client) CreateSavedSearch(opts ...SavedSearchOption) (*Response, error) {
+	return c.CreateSavedSearchContext(context.Background(), opts...)
+}
+
+func (c *Client) CreateSavedSearchContext(ctx context.Context, opts ...SavedSearchOption) (*Response, error) {
 	savedSearchOptions := newSavedSearchOptions(opts...)
 	marshalled, err := json.Marshal(savedSearchOptions)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Post(PrefixedPath("/user/searches/"))
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Post(PrefixedPath("/user/searches/"))
 }
 
 func (c *Client) UpdateSavedSearch(id string, opts ...SavedSearchOption) (*Response, error) {
+	return c.UpdateSavedSearchContext(context.Background(), id, opts...)
+}
+
+func (c *Client) UpdateSavedSearchContext(ctx context.Context, id string, opts ...SavedSearchOption) (*Response, error) {
 	savedSearchOptions := newSavedSearchOptions(opts...)
 	marshalled, err := json.Marshal(savedSearchOptions)
 	if err != nil {
 		return nil, err
 	}
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Put(
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Put(
 		PrefixedPath(fmt.Sprintf("/user/searches/%s/", id)),
 	)
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -149,7 +150,16 @@ func newSubscriptionOptions(opts ...SubscriptionOption) (*SubscriptionOptions, e
 	return &o, nil
 }
 
+// ListSubscriptions returns an iterator over the subscriptions of the current user.
+func (c *Client) ListSubscriptions(opts ...IteratorOption) (*PageIterator[json.RawMessage], error) {
+	return newIterator(c, PrefixedPath("/user/subscriptions/"), Paginator[json.RawMessage](ListPaginator[json.RawMessage]{Field: "subscriptions"}), opts...)
+}
+
 func (c *Client) CreateSubscription(opts ...SubscriptionOption) (*Response, error) {
+	return c.CreateSubscriptionContext(context.Background(), opts...)
+}
+
+func (c *Client) CreateSubscriptionContext(ctx context.Context, opts ...SubscriptionOption) (*Response, error) {
 	subscriptionOptions, err := newSubscriptionOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -160,12 +170,16 @@ func (c *Client) CreateSubscription(opts ...SubscriptionOption) (*Response, erro
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Post(
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Post(
 		PrefixedPath("/user/subscriptions/"),
 	)
 }
 
 func (c *Client) UpdateSubscription(id string, opts ...SubscriptionOption) (*Response, error) {
+	return c.UpdateSubscriptionContext(context.Background(), id, opts...)
+}
+
+func (c *Client) UpdateSubscriptionContext(ctx context.Context, id string, opts ...SubscriptionOption) (*Response, error) {
 	subscriptionOptions, err := newSubscriptionOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -176,7 +190,7 @@ func (c *Client) UpdateSubscription(id string, opts ...SubscriptionOption) (*Res
 		return nil, err
 	}
 
-	return c.NewRequest().SetBodyJSONBytes(marshalled).Put(
+	return c.NewRequest().SetContext(ctx).SetBodyJSONBytes(marshalled).Put(
 		PrefixedPath(fmt.Sprintf("/user/subscriptions/%s/", id)),
 	)
 }
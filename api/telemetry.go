@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/urlscan/urlscan-cli/api"
+
+type TelemetryOptions struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+type TelemetryOption func(*TelemetryOptions)
+
+// WithTracerProvider sets the tracer provider. The global one (otel.GetTracerProvider) is used by default.
+func WithTracerProvider(provider trace.TracerProvider) TelemetryOption {
+	return func(opts *TelemetryOptions) {
+		opts.TracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider. The global one (otel.GetMeterProvider) is used by default.
+func WithMeterProvider(provider metric.MeterProvider) TelemetryOption {
+	return func(opts *TelemetryOptions) {
+		opts.MeterProvider = provider
+	}
+}
+
+// telemetry holds the tracer and the instruments of a client.
+type telemetry struct {
+	tracer        trace.Tracer
+	requests      metric.Int64Counter
+	duration      metric.Float64Histogram
+	requestBytes  metric.Int64Counter
+	responseBytes metric.Int64Counter
+}
+
+func newTelemetry(opts ...TelemetryOption) (*telemetry, error) {
+	o := TelemetryOptions{TracerProvider: nil, MeterProvider: nil}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	if o.TracerProvider == nil {
+		o.TracerProvider = otel.GetTracerProvider()
+	}
+	if o.MeterProvider == nil {
+		o.MeterProvider = otel.GetMeterProvider()
+	}
+
+	meter := o.MeterProvider.Meter(instrumentationName, metric.WithInstrumentationVersion(version))
+	requests, err := meter.Int64Counter("urlscan.client.requests",
+		metric.WithDescription("Number of API requests"), metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("urlscan.client.request.duration",
+		metric.WithDescription("Duration of API requests including retries and rate limit sleeps"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	requestBytes, err := meter.Int64Counter("urlscan.client.request.body.size",
+		metric.WithDescription("Number of bytes sent in request bodies"), metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	responseBytes, err := meter.Int64Counter("urlscan.client.response.body.size",
+		metric.WithDescription("Number of bytes received in response bodies"), metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &telemetry{
+		tracer:        o.TracerProvider.Tracer(instrumentationName, trace.WithInstrumentationVersion(version)),
+		requests:      requests,
+		duration:      duration,
+		requestBytes:  requestBytes,
+		responseBytes: responseBytes,
+	}, nil
+}
+
+// SetTelemetry instruments the client with OpenTelemetry: a span and metrics per request (Do),
+// a span per task of Batch and BatchStream and a span per page of an iterator.
+func (c *Client) SetTelemetry(opts ...TelemetryOption) *Client {
+	t, err := newTelemetry(opts...)
+	if err != nil {
+		c.Logger().Warn("Failed to set up the telemetry", "error", err.Error())
+		return c
+	}
+	c.telemetry = t
+	return c
+}
+
+// requestStats collects what happens to a request beneath Do (in the built-in stages).
+type requestStats struct {
+	mu             sync.Mutex
+	retries        int
+	rateLimitSleep time.Duration
+}
+
+type requestStatsContextKey struct{}
+
+func requestStatsFromContext(ctx context.Context) *requestStats {
+	stats, _ := ctx.Value(requestStatsContextKey{}).(*requestStats)
+	return stats
+}
+
+func (s *requestStats) addRetry() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+}
+
+func (s *requestStats) addRateLimitSleep(d time.Duration) {
+	if s == nil || d <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimitSleep += d
+}
+
+// endpointTemplates replace the variable parts of API paths to keep the cardinality low.
+var endpointTemplates = []struct {
+	pattern  *regexp.Regexp
+	template string
+}{
+	{pattern: regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), template: "{uuid}"},
+	{pattern: regexp.MustCompile(`/[0-9a-f]{64}`), template: "/{hash}"},
+	{pattern: regexp.MustCompile(`^/api/v1/hostname/[^/]+`), template: "/api/v1/hostname/{hostname}"},
+	{pattern: regexp.MustCompile(`^/api/v1/user/(channels|searches|subscriptions|incidents)/[^/]+`), template: "/api/v1/user/$1/{id}"},
+	{pattern: regexp.MustCompile(`^/api/v1/livescan/[^/]+/`), template: "/api/v1/livescan/{scanner}/"},
+	{pattern: regexp.MustCompile(`^/api/v1/datadump/(list|link)/.+`), template: "/api/v1/datadump/$1/{path}"},
+}
+
+// endpointTemplate returns the template of an API path,
+// e.g. "/api/v1/result/68e26c59-2eae-437b-aeb1-cf750fafe7d7/" -> "/api/v1/result/{uuid}/".
+func endpointTemplate(path string) string {
+	for _, t := range endpointTemplates {
+		path = t.pattern.ReplaceAllString(path, t.template)
+	}
+	return path
+}
+
+// startRequest starts the span of a request to the API path with a body of requestBytes.
+// The returned function ends the span and records the metrics.
+func (t *telemetry) startRequest(ctx context.Context, req *http.Request, path string, requestBytes int64) (context.Context, func(*http.Response, int64, error)) {
+	if t == nil {
+		return ctx, func(*http.Response, int64, error) {}
+	}
+
+	template := endpointTemplate(path)
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.template", template),
+		attribute.String("server.address", req.URL.Hostname()),
+	}
+	ctx, span := t.tracer.Start(ctx, fmt.Sprintf("%s %s", req.Method, template),
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	stats := &requestStats{mu: sync.Mutex{}, retries: 0, rateLimitSleep: 0}
+	ctx = context.WithValue(ctx, requestStatsContextKey{}, stats)
+	start := time.Now()
+
+	return ctx, func(res *http.Response, responseBytes int64, err error) {
+		stats.mu.Lock()
+		retries, rateLimitSleep := stats.retries, stats.rateLimitSleep
+		stats.mu.Unlock()
+
+		span.SetAttributes(
+			attribute.Int("urlscan.retry_count", retries),
+			attribute.Float64("urlscan.rate_limit.sleep", rateLimitSleep.Seconds()),
+		)
+		if res != nil {
+			attrs = append(attrs, attribute.Int("http.response.status_code", res.StatusCode))
+			span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+			if res.StatusCode >= 400 {
+				span.SetStatus(codes.Error, strconv.Itoa(res.StatusCode))
+			}
+		}
+		if err != nil {
+			attrs = append(attrs, attribute.String("error.type", fmt.Sprintf("%T", err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		// the context of the request may be canceled already
+		metricCtx := context.WithoutCancel(ctx)
+		set := metric.WithAttributes(attrs...)
+		t.requests.Add(metricCtx, 1, set)
+		t.duration.Record(metricCtx, time.Since(start).Seconds(), set)
+		if requestBytes > 0 {
+			t.requestBytes.Add(metricCtx, requestBytes, set)
+		}
+		if responseBytes > 0 {
+			t.responseBytes.Add(metricCtx, responseBytes, set)
+		}
+	}
+}
+
+// startSpan starts an internal span (e.g. a batch task or an iterator page).
+func (t *telemetry) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(error, ...attribute.KeyValue)) {
+	if t == nil {
+		return ctx, func(error, ...attribute.KeyValue) {}
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error, attrs ...attribute.KeyValue) {
+		span.SetAttributes(attrs...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
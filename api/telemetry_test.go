@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestTelemetryClient() (*Client, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	exporter := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	c := newTestClient().SetTelemetry(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	return c, exporter, reader
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/v1/search/", want: "/api/v1/search/"},
+		{path: "/api/v1/result/68e26c59-2eae-437b-aeb1-cf750fafe7d7/", want: "/api/v1/result/{uuid}/"},
+		{path: "/screenshots/68e26c59-2eae-437b-aeb1-cf750fafe7d7.png", want: "/screenshots/{uuid}.png"},
+		{path: "/responses/0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef/", want: "/responses/{hash}/"},
+		{path: "/api/v1/hostname/example.com", want: "/api/v1/hostname/{hostname}"},
+		{path: "/api/v1/user/searches/my-search/", want: "/api/v1/user/searches/{id}/"},
+		{path: "/api/v1/livescan/de01/task/", want: "/api/v1/livescan/{scanner}/task/"},
+		{path: "/api/v1/datadump/list/hours/api/20260101/", want: "/api/v1/datadump/list/{path}"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, endpointTemplate(tt.path), tt.path)
+	}
+}
+
+func TestTelemetryRequest(t *testing.T) {
+	defer gock.Off()
+
+	path := "/api/v1/result/68e26c59-2eae-437b-aeb1-cf750fafe7d7/"
+	gock.New("http://testserver/").Get(path).Reply(http.StatusBadGateway)
+	gock.New("http://testserver/").Get(path).Reply(http.StatusOK).JSON(map[string]string{"foo": "bar"})
+
+	c, exporter, reader := newTestTelemetryClient()
+	c.SetRetryPolicy(newTestRetryPolicy())
+
+	_, err := c.NewRequest().Get(path)
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/v1/result/{uuid}/", spans[0].Name)
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "/api/v1/result/{uuid}/", attrs["url.template"].AsString())
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, int64(1), attrs["urlscan.retry_count"].AsInt64())
+	assert.Contains(t, attrs, attribute.Key("urlscan.rate_limit.sleep"))
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	requests, ok := metrics["urlscan.client.requests"].(metricdata.Sum[int64])
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), requests.DataPoints[0].Value)
+
+	duration, ok := metrics["urlscan.client.request.duration"].(metricdata.Histogram[float64])
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), duration.DataPoints[0].Count)
+
+	responseBytes, ok := metrics["urlscan.client.response.body.size"].(metricdata.Sum[int64])
+	assert.True(t, ok)
+	assert.Equal(t, int64(len("{\"foo\":\"bar\"}\n")), responseBytes.DataPoints[0].Value)
+}
+
+func TestTelemetryBatchAndIterator(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://testserver/").Get("/api/v1/search").Reply(http.StatusOK).
+		JSON(map[string]any{"results": []any{}, "total": 0, "has_more": false})
+	gock.New("http://testserver/").Get("/api/v1/result/68e26c59-2eae-437b-aeb1-cf750fafe7d7/").Reply(http.StatusOK).
+		JSON(map[string]string{"foo": "bar"})
+
+	c, exporter, _ := newTestTelemetryClient()
+
+	it, err := c.Search("page.domain:example.com")
+	assert.NoError(t, err)
+	for _, err := range it.Iterate() {
+		assert.NoError(t, err)
+	}
+
+	task := func(c *Client, ctx context.Context) mo.Result[*Response] {
+		return mo.TupleToResult(c.GetResultContext(ctx, "68e26c59-2eae-437b-aeb1-cf750fafe7d7"))
+	}
+	_, err = Batch(c, []BatchTask[*Response]{task}, WithBatchMaxConcurrency(1))
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	page, ok := spans["urlscan.iterator.page"]
+	assert.True(t, ok)
+	assert.Equal(t, "/api/v1/search", spanAttributes(page)["url.template"].AsString())
+	search, ok := spans["GET /api/v1/search"]
+	assert.True(t, ok)
+	assert.Equal(t, page.SpanContext.SpanID(), search.Parent.SpanID())
+
+	batchTask, ok := spans["urlscan.batch.task"]
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), spanAttributes(batchTask)["urlscan.batch.task.attempts"].AsInt64())
+	result, ok := spans["GET /api/v1/result/{uuid}/"]
+	assert.True(t, ok)
+	assert.Equal(t, batchTask.SpanContext.SpanID(), result.Parent.SpanID())
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/urlscan/urlscan-cli/pkg/utils"
+)
+
+var clearCacheCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached responses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return cmd.Usage()
+		}
+
+		return utils.NewDiskCache().Clear()
+	},
+}
+
+var statsCacheCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show response cache statistics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return cmd.Usage()
+		}
+
+		stats, err := utils.NewDiskCache().Stats()
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(b)) //nolint:errcheck
+
+		return nil
+	},
+}
+
+var cacheCmdLong = `Manage the on-disk response cache.
+
+Scan results, DOMs, screenshots and responses never change once a scan finishes, so they are cached on disk and served without hitting the API.
+Use --no-cache to bypass the cache.`
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage response cache",
+	Long:  cacheCmdLong,
+	// the API key is not required to manage the cache
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return setUp(cmd)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(clearCacheCmd)
+	cacheCmd.AddCommand(statsCacheCmd)
+
+	RootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+// Exit codes returned by the CLI. These are part of the public interface and must stay stable.
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitUnauthorized  = 3
+	ExitForbidden     = 4
+	ExitNotFound      = 5
+	ExitRateLimited   = 6
+	ExitQuotaExceeded = 7
+	ExitScanRefused   = 8
+	// ExitInterrupted is returned when the command is stopped by SIGINT or SIGTERM (128 + SIGINT).
+	ExitInterrupted = 130
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found, please set the URLSCAN_API_KEY environment variable or set it in keyring by `urlscan key set`")
+
+// ExitCode maps err to an exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, ErrAPIKeyNotFound), errors.Is(err, api.ErrUnauthorized):
+		return ExitUnauthorized
+	case errors.Is(err, api.ErrForbidden):
+		return ExitForbidden
+	case errors.Is(err, api.ErrNotFound):
+		return ExitNotFound
+	// check quota before rate limit since quota errors are also rate limit errors
+	case errors.Is(err, api.ErrQuotaExceeded):
+		return ExitQuotaExceeded
+	case errors.Is(err, api.ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, api.ErrScanBlocked), errors.Is(err, api.ErrDNSFailure):
+		return ExitScanRefused
+	default:
+		return ExitError
+	}
+}
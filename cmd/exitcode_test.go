@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitError},
+		{ErrAPIKeyNotFound, ExitUnauthorized},
+		{&api.JSONError{Status: 401, Message: "Unauthorized", Description: "", Raw: nil, Header: nil}, ExitUnauthorized},
+		{&api.JSONError{Status: 403, Message: "Forbidden", Description: "", Raw: nil, Header: nil}, ExitForbidden},
+		{fmt.Errorf("wrapped: %w", &api.JSONError{Status: 404, Message: "Not Found", Description: "", Raw: nil, Header: nil}), ExitNotFound},
+		{&api.JSONError{Status: 429, Message: "Rate limit exceeded", Description: "", Raw: nil, Header: nil}, ExitRateLimited},
+		{&api.JSONError{Status: 429, Message: "Rate limit exceeded", Description: "", Raw: nil, Header: http.Header{"X-Rate-Limit-Window": {"day"}}}, ExitQuotaExceeded},
+		{api.JSONError{Status: 404, Message: "Not Found", Description: "", Raw: nil, Header: nil}, ExitNotFound},
+		{&api.JSONError{Status: 400, Message: "DNS Error - Could not resolve domain", Description: "", Raw: nil, Header: nil}, ExitScanRefused},
+		{&api.JSONError{Status: 400, Message: "Scan prevented - Blocklisted domain", Description: "", Raw: nil, Header: nil}, ExitScanRefused},
+		{fmt.Errorf("failed to get datadump list: %w", context.Canceled), ExitInterrupted},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ExitCode(tt.err), "%v", tt.err)
+	}
+}
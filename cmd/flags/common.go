@@ -18,6 +18,12 @@ func AddLimitFlag(cmd *cobra.Command) {
 	cmd.Flags().IntP("limit", "l", 0, "Maximum number of results that will be returned by the iterator (default to --size, i.e. one page)")
 }
 
+// AddListFlags adds --limit and --all to a command listing items of a non-paginated endpoint.
+func AddListFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP("limit", "l", 0, "Maximum number of items that will be returned (default 0, i.e. all items)")
+	AddAllFlag(cmd)
+}
+
 func AddSizeFlag(cmd *cobra.Command, value int) {
 	cmd.Flags().IntP("size", "s", value, "Number of results returned by the iterator in each batch")
 }
@@ -77,3 +83,20 @@ func AddDirectoryPrefixFlag(cmd *cobra.Command) {
 func AddRefangFlag(cmd *cobra.Command) {
 	cmd.Flags().Bool("refang", false, "Refang an input (convert '[.]' back to '.' and so on)")
 }
+
+func AddDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Print the quota plan (how many requests fit in the remaining minute/hour/day quotas) without sending the requests")
+}
+
+func AddQuotaPolicyFlag(cmd *cobra.Command) {
+	cmd.Flags().String("quota-policy", "wait", "What to do when the job exceeds the remaining quota: wait (for the quota reset), truncate (to the remaining quota), abort")
+}
+
+func AddPrefetchFlag(cmd *cobra.Command) {
+	cmd.Flags().Int("prefetch", 0, "Number of pages to request ahead in the background while the current page is processed (0 disables prefetching)")
+}
+
+func AddResumeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("resume", "", "Job name to checkpoint the progress as, a rerun with the same job name continues where it stopped")
+	cmd.Flags().StringP("output", "o", "", "Output file of --resume, the results are appended to it as JSON Lines (default <job-name>.jsonl)")
+}
@@ -1,7 +1,9 @@
 package datadump
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -19,6 +21,7 @@ var DownloadCmdExample = `  urlscan pro datadump download days/api/20260101.gz
   # use --follow option to download all files from a datadump path
   # for example, the following commands download all the files listed by 'urlscan pro datadump list hours/dom/20260101/'
   # note: --follow memoizes downloaded files in a local database to avoid re-downloading, so it's safe to run it periodically
+  # note: an interrupted download is kept as <output>.part and resumed on the next run
   urlscan pro datadump download hours/dom/20260101/ --follow
   # if date is not provided, all the available files (files within the last 7 days) will be downloaded
   urlscan pro datadump download hours/api/ --follow`
@@ -74,15 +77,19 @@ var downloadCmd = &cobra.Command{
 		// explode paths to download
 		paths := []string{path}
 		if follow {
-			missingPaths, err := findMissingPaths(db, client, path, force)
+			missingPaths, err := findMissingPaths(cmd.Context(), db, client, path, force)
 			if err != nil {
 				return err
 			}
 			paths = missingPaths
 		}
 
-		for _, path := range paths {
-			if err := download(client, db, path, output, directoryPrefix, force, extract); err != nil {
+		for i, path := range paths {
+			if err := download(cmd.Context(), client, db, path, output, directoryPrefix, force, extract); err != nil {
+				// the interrupted file is recorded as partial and downloaded again by a rerun
+				if follow && cmd.Context().Err() != nil {
+					client.Logger().Warn("Interrupted, rerun the command to download the remaining files", "downloaded", i, "remaining", len(paths)-i)
+				}
 				return err
 			}
 		}
@@ -91,14 +98,25 @@ var downloadCmd = &cobra.Command{
 	},
 }
 
-func download(client *utils.APIClient, db *utils.Database, path, output, directoryPrefix string, force, extract bool) error {
+func download(ctx context.Context, client *utils.APIClient, db *utils.Database, path, output, directoryPrefix string, force, extract bool) error {
 	if output == "" {
 		output = filepath.Base(path)
 	}
+	localPath := filepath.Join(directoryPrefix, output)
 
-	err := utils.DownloadWithSpinner(
+	// record the partial state so an interrupted download is not considered as downloaded
+	_, err := os.Stat(localPath)
+	if force || os.IsNotExist(err) {
+		err = db.SetDataDumpPartial(path, localPath)
+		if err != nil {
+			return fmt.Errorf("failed to update the database: %w", err)
+		}
+	}
+
+	err = utils.DownloadWithSpinner(
 		utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(ctx),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadDirectoryPrefix(directoryPrefix),
 			utils.WithDownloadForce(force),
@@ -109,7 +127,7 @@ func download(client *utils.APIClient, db *utils.Database, path, output, directo
 	}
 
 	// update the database after successful download
-	err = db.SetDataDump(path, filepath.Join(directoryPrefix, output))
+	err = db.SetDataDump(path, localPath)
 	if err != nil {
 		return fmt.Errorf("failed to update the database: %w", err)
 	}
@@ -125,8 +143,8 @@ func download(client *utils.APIClient, db *utils.Database, path, output, directo
 	return nil
 }
 
-func findMissingPaths(db *utils.Database, client *utils.APIClient, path string, force bool) ([]string, error) {
-	list, err := client.BulkGetDataDumpList(path)
+func findMissingPaths(ctx context.Context, db *utils.Database, client *utils.APIClient, path string, force bool) ([]string, error) {
+	list, err := client.BulkGetDataDumpListContext(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get datadump list: %w", err)
 	}
@@ -61,7 +61,7 @@ func TestDownload(t *testing.T) {
 		client := newTestClient()
 		tmpDir := t.TempDir()
 
-		err := download(client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, false, false)
+		err := download(t.Context(), client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, false, false)
 		assert.NoError(t, err)
 
 		// verify file was downloaded
@@ -94,7 +94,7 @@ func TestDownload(t *testing.T) {
 		client := newTestClient()
 		tmpDir := t.TempDir()
 
-		err := download(client, db, "hours/api/20260101/20260101-01.gz", "custom.gz", tmpDir, false, false)
+		err := download(t.Context(), client, db, "hours/api/20260101/20260101-01.gz", "custom.gz", tmpDir, false, false)
 		assert.NoError(t, err)
 
 		// verify file was downloaded with custom name
@@ -122,7 +122,7 @@ func TestDownload(t *testing.T) {
 		err := os.WriteFile(existingFile, []byte("existing content"), 0o644)
 		assert.NoError(t, err)
 
-		err = download(client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, false, false)
+		err = download(t.Context(), client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, false, false)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "already exists")
 
@@ -151,7 +151,7 @@ func TestDownload(t *testing.T) {
 		err := os.WriteFile(existingFile, []byte("old content"), 0o644)
 		assert.NoError(t, err)
 
-		err = download(client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, true, false)
+		err = download(t.Context(), client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, true, false)
 		assert.NoError(t, err)
 
 		// verify file was overwritten
@@ -163,6 +163,77 @@ func TestDownload(t *testing.T) {
 	})
 }
 
+func TestDownloadPartial(t *testing.T) {
+	defer gock.Off()
+
+	t.Run("partial download is not considered as downloaded", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver").
+			Get("/api/v1/datadump/list/hours/api/20260101/").
+			Reply(200).
+			JSON(map[string]any{
+				"files": []map[string]any{
+					{"path": "hours/api/20260101/20260101-01.gz", "size": 100},
+				},
+			})
+
+		db := newTestDatabase(t)
+		defer db.Close() // nolint:errcheck
+
+		tmpDir := t.TempDir()
+		localPath := filepath.Join(tmpDir, "20260101-01.gz")
+		err := os.WriteFile(localPath, []byte("test"), 0o644)
+		assert.NoError(t, err)
+
+		err = db.SetDataDumpPartial("hours/api/20260101/20260101-01.gz", localPath)
+		assert.NoError(t, err)
+
+		client := newTestClient()
+
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hours/api/20260101/20260101-01.gz"}, paths)
+
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("resumes an interrupted download", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver").
+			Get("/api/v1/datadump/link/hours/api/20260101/20260101-01.gz").
+			MatchHeader("Range", "bytes=5-").
+			Reply(206).
+			SetHeader("Content-Range", "bytes 5-11/12").
+			BodyString("content")
+
+		db := newTestDatabase(t)
+		defer db.Close() // nolint:errcheck
+
+		client := newTestClient()
+		tmpDir := t.TempDir()
+
+		outputPath := filepath.Join(tmpDir, "20260101-01.gz")
+		err := os.WriteFile(api.PartialPath(outputPath), []byte("test "), 0o644)
+		assert.NoError(t, err)
+
+		err = download(t.Context(), client, db, "hours/api/20260101/20260101-01.gz", "", tmpDir, false, false)
+		assert.NoError(t, err)
+
+		content, err := os.ReadFile(outputPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "test content", string(content))
+
+		entry, exists, err := db.GetDataDumpEntry("hours/api/20260101/20260101-01.gz")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, utils.DataDumpStateComplete, entry.State)
+
+		assert.True(t, gock.IsDone())
+	})
+}
+
 func TestDownloadWithFollow(t *testing.T) {
 	defer gock.Off()
 
@@ -198,13 +269,13 @@ func TestDownloadWithFollow(t *testing.T) {
 		tmpDir := t.TempDir()
 
 		// find missing paths
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 2)
 
 		// download each file
 		for _, path := range paths {
-			err := download(client, db, path, "", tmpDir, false, false)
+			err := download(t.Context(), client, db, path, "", tmpDir, false, false)
 			assert.NoError(t, err)
 		}
 
@@ -263,14 +334,14 @@ func TestDownloadWithFollow(t *testing.T) {
 		assert.NoError(t, err)
 
 		// find missing paths (should only return second file)
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 1)
 		assert.Equal(t, "hours/api/20260101/20260101-02.gz", paths[0])
 
 		// download missing files
 		for _, path := range paths {
-			err := download(client, db, path, "", tmpDir, false, false)
+			err := download(t.Context(), client, db, path, "", tmpDir, false, false)
 			assert.NoError(t, err)
 		}
 
@@ -326,13 +397,13 @@ func TestDownloadWithFollow(t *testing.T) {
 		assert.NoError(t, err)
 
 		// find paths with force=true (should return both files)
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", true)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", true)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 2)
 
 		// download all files with force
 		for _, path := range paths {
-			err := download(client, db, path, "", tmpDir, true, false)
+			err := download(t.Context(), client, db, path, "", tmpDir, true, false)
 			assert.NoError(t, err)
 		}
 
@@ -376,7 +447,7 @@ func TestDownloadWithFollow(t *testing.T) {
 		assert.NoError(t, err)
 
 		// find missing paths (should return empty)
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 0)
 
@@ -406,7 +477,7 @@ func TestFindMissingPaths(t *testing.T) {
 
 		client := newTestClient()
 
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 3)
 		assert.Contains(t, paths, "hours/api/20260101/20260101-01.gz")
@@ -445,7 +516,7 @@ func TestFindMissingPaths(t *testing.T) {
 
 		client := newTestClient()
 
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 2)
 		assert.Contains(t, paths, "hours/api/20260101/20260101-01.gz")
@@ -481,7 +552,7 @@ func TestFindMissingPaths(t *testing.T) {
 
 		client := newTestClient()
 
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", true)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", true)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 2)
 		assert.Contains(t, paths, "hours/api/20260101/20260101-01.gz")
@@ -515,7 +586,7 @@ func TestFindMissingPaths(t *testing.T) {
 
 		client := newTestClient()
 
-		paths, err := findMissingPaths(db, client, "hours/api/20260101/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/20260101/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 0)
 
@@ -544,7 +615,7 @@ func TestFindMissingPaths(t *testing.T) {
 
 		client := newTestClient()
 
-		paths, err := findMissingPaths(db, client, "hours/api/", false)
+		paths, err := findMissingPaths(t.Context(), db, client, "hours/api/", false)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 7)
 
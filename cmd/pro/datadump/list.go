@@ -38,7 +38,7 @@ var listCmd = &cobra.Command{
 			return err
 		}
 
-		result, err := client.BulkGetDataDumpList(path)
+		result, err := client.BulkGetDataDumpListContext(cmd.Context(), path)
 		if err != nil {
 			return err
 		}
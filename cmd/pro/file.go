@@ -50,6 +50,7 @@ var fileCmd = &cobra.Command{
 
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadURL(path),
 			utils.WithDownloadOutput(filename),
 			utils.WithDownloadForce(force),
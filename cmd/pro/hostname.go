@@ -25,7 +25,9 @@ func newHostnameResults() HostnameResults {
 }
 
 var hostnameCmdExample = `  urlscan pro hostname <hostname>
-  echo "<hostname>" | urlscan pro hostname -`
+  echo "<hostname>" | urlscan pro hostname -
+  # export all results to example.jsonl, rerun to continue after an interruption
+  urlscan pro hostname example.com --all --resume example`
 
 var hostnameLong = `To have the same idiom with the search command, this command has the following specs:
 
@@ -33,7 +35,10 @@ var hostnameLong = `To have the same idiom with the search command, this command
   - limit: the maximum number of results that will be returned by the iterator.
   - size: the number of results returned by the iterator in each batch (equivalent to the API endpoint's "limit" query parameter).
 - Response:
-  - hasMore: indicates more results are available.`
+  - hasMore: indicates more results are available.
+
+With --resume <job-name>, results are appended to the output file as JSON Lines and the page state is checkpointed
+after every page. Rerunning the same command with the same job name continues where it stopped.`
 
 var hostnameCmd = &cobra.Command{
 	Use:     "hostname",
@@ -43,7 +48,7 @@ var hostnameCmd = &cobra.Command{
 	Annotations: map[string]string{
 		"args": "exact1",
 	},
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		if len(args) != 1 {
 			return cmd.Usage()
 		}
@@ -67,12 +72,61 @@ var hostnameCmd = &cobra.Command{
 			return err
 		}
 
-		it, err := client.IterateHostname(hostname,
+		opts := []api.IteratorOption{
+			api.HostnameIteratorContext(cmd.Context()),
 			api.HostnameIteratorLimit(limit),
 			api.HostnameIteratorSize(size),
 			api.HostnameIteratorPageState(pageState),
 			api.HostnameIteratorAll(all),
-		)
+		}
+
+		resume, _ := cmd.Flags().GetString("resume")
+		if resume != "" {
+			output, _ := cmd.Flags().GetString("output")
+			var job *utils.ResumableJob
+			job, err = utils.OpenResumableJob(resume, fmt.Sprintf("pro hostname %q", hostname), output)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := job.Close()
+				if closeErr != nil && err == nil {
+					err = closeErr
+				}
+			}()
+
+			if job.Done() {
+				client.Logger().Info("The job is already complete, remove the output file to start over", "job", resume, "output", job.Output())
+				return nil
+			}
+			opts = append(opts, api.HostnameIteratorCheckpoint(job.Save))
+			cp, ok := job.Checkpoint()
+			if ok {
+				client.Logger().Info("Resuming the job", "job", resume, "count", cp.Count)
+				opts = append(opts, api.HostnameIteratorResume(cp))
+			}
+
+			it, err := client.IterateHostname(hostname, opts...)
+			if err != nil {
+				return err
+			}
+			for result, err := range it.Iterate() {
+				if err != nil {
+					if cmd.Context().Err() != nil {
+						client.Logger().Warn("Interrupted, rerun the same command to resume the job", "job", resume, "output", job.Output())
+					}
+					return err
+				}
+				err = job.Write(*result)
+				if err != nil {
+					return err
+				}
+			}
+			client.Logger().Info("Finished the job", "job", resume, "output", job.Output(), "hasMore", it.HasMore)
+			return job.Finish(!it.HasMore)
+		}
+
+		it, err := client.IterateHostname(hostname, opts...)
 		if err != nil {
 			return err
 		}
@@ -80,22 +134,38 @@ var hostnameCmd = &cobra.Command{
 		results := newHostnameResults()
 		for result, err := range it.Iterate() {
 			if err != nil {
+				if cmd.Context().Err() == nil {
+					return err
+				}
+				// print the results collected so far, the page state is the one of the next page
+				results.PageState = it.Cursor()
+				results.HasMore = true
+				printErr := printHostnameResults(results)
+				if printErr != nil {
+					return printErr
+				}
+				client.Logger().Warn("Interrupted, rerun with --page-state to continue", "count", len(results.Results), "pageState", it.Cursor())
 				return err
 			}
 			results.Results = append(results.Results, *result)
 		}
 
+		results.PageState = it.Cursor()
 		results.HasMore = it.HasMore
 
-		b, err := json.MarshalIndent(results, "", "  ")
-		if err != nil {
-			return err
-		}
+		return printHostnameResults(results)
+	},
+}
 
-		fmt.Print(string(b))
+func printHostnameResults(results HostnameResults) error {
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
 
-		return nil
-	},
+	fmt.Print(string(b))
+
+	return nil
 }
 
 func init() {
@@ -103,6 +173,7 @@ func init() {
 	flags.AddLimitFlag(hostnameCmd)
 	flags.AddAllFlag(hostnameCmd)
 	hostnameCmd.Flags().StringP("page-state", "p", "", "Returns additional results starting from this page state from the previous API call")
+	flags.AddResumeFlags(hostnameCmd)
 
 	RootCmd.AddCommand(hostnameCmd)
 }
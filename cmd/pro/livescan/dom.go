@@ -51,6 +51,7 @@ var domCmd = &cobra.Command{
 		url := api.PrefixedPath(fmt.Sprintf("/livescan/%s/dom/%s", scannerId, scanId))
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadURL(url),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadForce(force),
@@ -51,6 +51,7 @@ var downloadCmd = &cobra.Command{
 		url := api.PrefixedPath(fmt.Sprintf("/livescan/%s/download/%s/", scannerId, fileHash))
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadURL(url),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadForce(force),
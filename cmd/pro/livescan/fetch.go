@@ -68,6 +68,7 @@ var fetchCmd = &cobra.Command{
 
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadURL(url),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadForce(force),
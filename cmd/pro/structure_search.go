@@ -34,6 +34,7 @@ var structureSearchCmd = &cobra.Command{
 			limit = size
 		}
 		searchAfter, _ := cmd.Flags().GetString("search-after")
+		prefetch, _ := cmd.Flags().GetInt("prefetch")
 		q, _ := cmd.Flags().GetString("query")
 
 		reader := utils.StringReaderFromCmdArgs(args)
@@ -52,11 +53,13 @@ var structureSearchCmd = &cobra.Command{
 		}
 		it, err := client.StructureSearch(
 			uuid,
+			api.IteratorContext(cmd.Context()),
 			api.IteratorSize(size),
 			api.IteratorLimit(limit),
 			api.IteratorSearchAfter(searchAfter),
 			api.IteratorQuery(q),
 			api.IteratorAll(all),
+			api.IteratorPrefetch(prefetch),
 		)
 		if err != nil {
 			return err
@@ -88,6 +91,7 @@ func init() {
 	flags.AddSizeFlag(structureSearchCmd, 1_000)
 	flags.AddLimitFlag(structureSearchCmd)
 	flags.AddAllFlag(structureSearchCmd)
+	flags.AddPrefetchFlag(structureSearchCmd)
 	structureSearchCmd.Flags().String("search-after", "", "For retrieving the next batch of results, value of the sort attribute of the last (oldest) result you received (comma-separated)")
 
 	structureSearchCmd.Flags().StringP("query", "q", "", "Additional query filter")
@@ -1,11 +1,10 @@
 package subscription
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/urlscan/urlscan-cli/api"
+	"github.com/urlscan/urlscan-cli/cmd/flags"
 	"github.com/urlscan/urlscan-cli/pkg/utils"
 )
 
@@ -21,17 +20,24 @@ var listCmd = &cobra.Command{
 			return err
 		}
 
-		resp, err := client.NewRequest().Get(api.PrefixedPath("/user/subscriptions/"))
+		limit, _ := cmd.Flags().GetInt("limit")
+		all, _ := cmd.Flags().GetBool("all")
+
+		it, err := client.ListSubscriptions(
+			api.IteratorContext(cmd.Context()),
+			api.IteratorLimit(limit),
+			api.IteratorAll(all || limit == 0),
+		)
 		if err != nil {
 			return err
 		}
 
-		fmt.Print(resp.PrettyJSON())
-
-		return nil
+		return utils.PrintList("subscriptions", it)
 	},
 }
 
 func init() {
+	flags.AddListFlags(listCmd)
+
 	RootCmd.AddCommand(listCmd)
 }
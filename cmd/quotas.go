@@ -1,10 +1,11 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/urlscan/urlscan-cli/api"
 	"github.com/urlscan/urlscan-cli/pkg/utils"
 )
 
@@ -21,12 +22,17 @@ var quotasCmd = &cobra.Command{
 			return err
 		}
 
-		resp, err := client.NewRequest().Get(api.PrefixedPath("/quotas"))
+		quotas, err := client.GetQuotasContext(cmd.Context())
 		if err != nil {
 			return err
 		}
 
-		fmt.Print(resp.PrettyJSON())
+		var b bytes.Buffer
+		err = json.Indent(&b, quotas.Raw, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Print(b.String())
 
 		return nil
 	},
@@ -68,3 +68,21 @@ func commandPathToArgs(cmd *cobra.Command) []string {
 	}
 	return args
 }
+
+func TestCacheCommandSetsUp(t *testing.T) {
+	t.Cleanup(func() {
+		assert.NoError(t, RootCmd.PersistentFlags().Set("log-level", "info"))
+	})
+
+	root := RootCmd
+	root.SetArgs([]string{"cache", "stats", "--log-level", "invalid"})
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+
+	// the logger is set up (and fails) before the command runs
+	err := root.Execute()
+	assert.Error(t, err)
+	assert.NotContains(t, out.String(), `"entries"`)
+}
@@ -3,9 +3,11 @@ package scan
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/samber/lo"
 	"github.com/samber/mo"
 	"github.com/spf13/cobra"
 	"github.com/urlscan/urlscan-cli/api"
@@ -23,12 +25,26 @@ type scanner struct {
 	directoryPrefix string
 	screenshot      bool
 	dom             bool
+	jsonl           bool
+	progress        *utils.BatchProgress
 	ctx             context.Context
 }
 
-func (s *scanner) newBatchScanWithDownloadTask(url string) api.BatchTask[*api.Response] {
+// newBatchScanTask submits a scan and marks it as submitted in the progress line.
+func (s *scanner) newBatchScanTask(index int, url string) api.BatchTask[*api.Response] {
+	task := s.client.NewBatchScanTask(url, s.scanOpts...)
 	return func(c *api.Client, ctx context.Context) mo.Result[*api.Response] {
-		req := c.NewScanRequest(url, s.scanOpts...)
+		result := task(c, ctx)
+		if result.IsOk() && s.progress != nil {
+			s.progress.Submitted(index)
+		}
+		return result
+	}
+}
+
+func (s *scanner) newBatchScanWithDownloadTask(index int, url string) api.BatchTask[*api.Response] {
+	return func(c *api.Client, ctx context.Context) mo.Result[*api.Response] {
+		req := c.NewScanRequest(url, s.scanOpts...).SetContext(ctx)
 		resp, err := req.Do()
 		if err != nil {
 			return mo.Err[*api.Response](err)
@@ -39,7 +55,15 @@ func (s *scanner) newBatchScanWithDownloadTask(url string) api.BatchTask[*api.Re
 		if err != nil {
 			return mo.Err[*api.Response](err)
 		}
+		if s.progress != nil {
+			s.progress.Submitted(index)
+			s.progress.Waiting(index)
+		}
 		_, err = c.WaitAndGetResult(ctx, scanResult.UUID, s.maxWait)
+		// keep the submission (its UUID) of a scan which was interrupted while waiting
+		if err != nil && s.ctx.Err() != nil {
+			return mo.Ok(resp)
+		}
 		if err != nil {
 			return mo.Err[*api.Response](err)
 		}
@@ -47,6 +71,7 @@ func (s *scanner) newBatchScanWithDownloadTask(url string) api.BatchTask[*api.Re
 		if s.screenshot {
 			downloadOpts := utils.NewDownloadOptions(
 				utils.WithDownloadClient(s.client),
+				utils.WithDownloadContext(ctx),
 				utils.WithDownloadScreenshot(scanResult.UUID),
 				utils.WithDownloadOutput(fmt.Sprintf("%s.png", scanResult.UUID)),
 				utils.WithDownloadForce(s.force),
@@ -62,6 +87,7 @@ func (s *scanner) newBatchScanWithDownloadTask(url string) api.BatchTask[*api.Re
 		if s.dom {
 			downloadOpts := utils.NewDownloadOptions(
 				utils.WithDownloadClient(s.client),
+				utils.WithDownloadContext(ctx),
 				utils.WithDownloadDOM(scanResult.UUID),
 				utils.WithDownloadOutput(scanResult.UUID),
 				utils.WithDownloadForce(s.force),
@@ -82,13 +108,21 @@ func (s *scanner) do(urls []string) error {
 	tasks := make([]api.BatchTask[*api.Response], len(urls))
 	for i, url := range urls {
 		if s.wait {
-			tasks[i] = s.newBatchScanWithDownloadTask(url)
+			tasks[i] = s.newBatchScanWithDownloadTask(i, url)
 		} else {
-			tasks[i] = s.client.NewBatchScanTask(url, s.scanOpts...)
+			tasks[i] = s.newBatchScanTask(i, url)
 		}
 	}
 
+	s.progress = utils.NewBatchProgress(len(tasks))
+	s.batchOpts = append(s.batchOpts, api.WithBatchOnDone(s.progress.OnDone))
+
+	if s.jsonl {
+		return s.stream(urls, tasks)
+	}
+
 	results, err := api.Batch(s.client.Client, tasks, s.batchOpts...)
+	s.progress.Finish()
 	if err != nil {
 		return err
 	}
@@ -102,7 +136,53 @@ func (s *scanner) do(urls []string) error {
 
 	fmt.Print(string(b))
 
-	return nil
+	return s.interrupted(results)
+}
+
+// stream prints the result of each task as a JSON line as soon as the task completes.
+func (s *scanner) stream(urls []string, tasks []api.BatchTask[*api.Response]) error {
+	results := make([]mo.Result[*api.Response], len(tasks))
+	for i := range results {
+		results[i] = mo.Err[*api.Response](api.ErrTaskNotStarted)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for i, result := range api.BatchStream(s.client.Client, tasks, s.batchOpts...) {
+		results[i] = result
+		err := encoder.Encode(utils.NewBatchJSONResultPair(urls[i], result))
+		if err != nil {
+			s.progress.Finish()
+			return err
+		}
+	}
+	s.progress.Finish()
+
+	// print the URLs which were not started, so the output covers all the URLs
+	if s.ctx.Err() != nil {
+		for i, result := range results {
+			if !errors.Is(result.Error(), api.ErrTaskNotStarted) {
+				continue
+			}
+			err := encoder.Encode(utils.NewBatchJSONResultPair(urls[i], result))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.interrupted(results)
+}
+
+// interrupted logs a summary of the results and returns the context error if the command was interrupted.
+func (s *scanner) interrupted(results []mo.Result[*api.Response]) error {
+	if s.ctx.Err() == nil {
+		return nil
+	}
+
+	failed := lo.CountBy(results, func(r mo.Result[*api.Response]) bool { return r.IsError() })
+	s.client.Logger().Warn("Interrupted, resubmit the URLs whose result is an error to continue",
+		"total", len(results), "submitted", len(results)-failed, "remaining", failed)
+	return s.ctx.Err()
 }
 
 func newScanner(cmd *cobra.Command) (*scanner, error) {
@@ -110,6 +190,8 @@ func newScanner(cmd *cobra.Command) (*scanner, error) {
 
 	maxConcurrency, _ := cmd.Flags().GetInt("max-concurrency")
 	timeout, _ := cmd.Flags().GetInt("timeout")
+	taskTimeout, _ := cmd.Flags().GetInt("task-timeout")
+	taskRetries, _ := cmd.Flags().GetInt("task-retries")
 
 	wait := newWaitFlag(cmd)
 	maxWait, _ := cmd.Flags().GetInt("max-wait")
@@ -118,6 +200,7 @@ func newScanner(cmd *cobra.Command) (*scanner, error) {
 	dom := newDOMFlag(cmd)
 	force, _ := cmd.Flags().GetBool("force")
 	directoryPrefix, _ := cmd.Flags().GetString("directory-prefix")
+	jsonl, _ := cmd.Flags().GetBool("jsonl")
 
 	// override wait if dom or screenshot flag is set
 	wait = wait || screenshot || dom
@@ -127,19 +210,30 @@ func newScanner(cmd *cobra.Command) (*scanner, error) {
 		return nil, err
 	}
 
+	batchOpts := []api.BatchOption{
+		api.WithBatchMaxConcurrency(maxConcurrency),
+		api.WithBatchTimeout(timeout),
+		api.WithBatchTaskTimeout(taskTimeout),
+		api.WithBatchContext(cmd.Context()),
+	}
+	if taskRetries > 0 {
+		retryPolicy := api.DefaultRetryPolicy()
+		retryPolicy.MaxAttempts = taskRetries + 1
+		batchOpts = append(batchOpts, api.WithBatchRetryPolicy(retryPolicy))
+	}
+
 	return &scanner{
-		client:   client,
-		scanOpts: scanOpts,
-		batchOpts: []api.BatchOption{
-			api.WithBatchMaxConcurrency(maxConcurrency),
-			api.WithBatchTimeout(timeout),
-		},
+		client:          client,
+		scanOpts:        scanOpts,
+		batchOpts:       batchOpts,
 		wait:            wait,
 		maxWait:         maxWait,
 		dom:             dom,
 		screenshot:      screenshot,
 		force:           force,
 		directoryPrefix: directoryPrefix,
+		jsonl:           jsonl,
+		progress:        nil,
 		ctx:             cmd.Context(),
 	}, nil
 }
@@ -148,12 +242,32 @@ var bulkSubmitCmdExample = `  urlscan scan bulk-submit <url>...
   # submit with a file containing URLs per line, space, or tab
   urlscan scan bulk-submit list_of_urls.txt
   # combine the file input and the URL input
-  urlscan scan bulk-submit list_of_urls.txt <url>`
+  urlscan scan bulk-submit list_of_urls.txt <url>
+  # check how many URLs fit in the remaining quota without submitting them
+  urlscan scan bulk-submit list_of_urls.txt --dry-run
+  # print each result as a JSON line as soon as its scan is done
+  urlscan scan bulk-submit list_of_urls.txt --wait --jsonl > results.jsonl`
 
 var bulkSubmitCmdLong = `Submit multiple URLs to scan in bulk.
 
 This command allows you to submit a list of URLs for scanning in bulk. You can provide URLs via command line arguments or through a file.
-Note that the URLs will be validated before submission, and only valid URLs will be processed.`
+Note that the URLs will be validated before submission, and only valid URLs will be processed.
+
+Before submitting, the remaining quotas of the visibility are checked. --quota-policy decides what happens
+when there are more URLs than the remaining quota of the current minute/hour/day windows:
+wait for the quota reset, truncate the URLs to the remaining quota, or abort without submitting anything.
+Use --dry-run to only print the plan.
+
+By default, the results are printed as a JSON array once all the scans are done. With --jsonl, each result
+({"key": <url>, "result": ...}) is printed as a JSON line as soon as it's done, in the order of completion.
+
+When stderr is a terminal, a progress line (submitted / waiting / done / failed) is drawn on it. A URL is
+given --task-timeout seconds and retried --task-retries times on a transient error. Note that a retry
+submits the URL again.
+
+On Ctrl-C (SIGINT), the running scans are stopped and the results collected so far are printed, including
+the submissions (UUIDs) of the scans which were waiting for their results. The URLs which were not done have
+an error result. The command exits with code 130.`
 
 var bulkSubmitCmd = &cobra.Command{
 	Use:     "bulk-submit <url>...",
@@ -193,7 +307,23 @@ var bulkSubmitCmd = &cobra.Command{
 			return err
 		}
 
-		return scanner.do(urls)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		quotaPolicy, _ := cmd.Flags().GetString("quota-policy")
+		planner, err := utils.NewQuotaPlanner(scanner.client, quotaPolicy, dryRun, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		// scans are counted against the quota of their visibility
+		visibility, _ := cmd.Flags().GetString("visibility")
+		if visibility == "" {
+			visibility = "public"
+		}
+		n, err := planner.Plan(cmd.Context(), visibility, len(urls), 0)
+		if err != nil || planner.DryRun() {
+			return err
+		}
+
+		return scanner.do(urls[:n])
 	},
 }
 
@@ -201,9 +331,14 @@ func init() {
 	addScanFlags(bulkSubmitCmd)
 	flags.AddForceFlag(bulkSubmitCmd)
 	flags.AddDirectoryPrefixFlag(bulkSubmitCmd)
+	flags.AddDryRunFlag(bulkSubmitCmd)
+	flags.AddQuotaPolicyFlag(bulkSubmitCmd)
 
 	bulkSubmitCmd.Flags().Int("max-concurrency", 5, "Maximum number of concurrent requests for batch operation")
+	bulkSubmitCmd.Flags().Bool("jsonl", false, "Print each result as a JSON line as soon as it's done instead of a JSON array at the end")
 	bulkSubmitCmd.Flags().Int("timeout", 60*30, "Timeout for the batch operation in seconds, 0 means no timeout")
+	bulkSubmitCmd.Flags().Int("task-timeout", 0, "Timeout for each URL (submission and --wait) in seconds, 0 means no timeout")
+	bulkSubmitCmd.Flags().Int("task-retries", 0, "Number of retries of a URL failed with a transient error (5xx, rate limit, network error or --task-timeout)")
 
 	RootCmd.AddCommand(bulkSubmitCmd)
 }
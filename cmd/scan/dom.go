@@ -47,6 +47,7 @@ var domCmd = &cobra.Command{
 		}
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadDOM(uuid),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadForce(force),
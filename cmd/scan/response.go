@@ -47,6 +47,7 @@ var responseCmd = &cobra.Command{
 		}
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadResponse(fileHash),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadForce(force),
@@ -48,6 +48,7 @@ var screenshotCmd = &cobra.Command{
 		}
 		opts := utils.NewDownloadOptions(
 			utils.WithDownloadClient(client),
+			utils.WithDownloadContext(cmd.Context()),
 			utils.WithDownloadScreenshot(uuid),
 			utils.WithDownloadOutput(output),
 			utils.WithDownloadForce(force),
@@ -48,7 +48,8 @@ var submitCmd = &cobra.Command{
 			return err
 		}
 
-		scanResult, err := client.Scan(url, scanOpts...)
+		ctx := cmd.Context()
+		scanResult, err := client.ScanContext(ctx, url, scanOpts...)
 		if err != nil {
 			return err
 		}
@@ -58,7 +59,6 @@ var submitCmd = &cobra.Command{
 			return nil
 		}
 
-		ctx := cmd.Context()
 		waitResult, err := client.WaitAndGetResult(ctx, scanResult.UUID, maxWait)
 		if err != nil {
 			return err
@@ -69,6 +69,7 @@ var submitCmd = &cobra.Command{
 		if screenshot {
 			downloadOpts := utils.NewDownloadOptions(
 				utils.WithDownloadClient(client),
+				utils.WithDownloadContext(cmd.Context()),
 				utils.WithDownloadScreenshot(scanResult.UUID),
 				utils.WithDownloadOutput(fmt.Sprintf("%s.png", scanResult.UUID)),
 				utils.WithDownloadForce(force),
@@ -83,6 +84,7 @@ var submitCmd = &cobra.Command{
 		if dom {
 			downloadOpts := utils.NewDownloadOptions(
 				utils.WithDownloadClient(client),
+				utils.WithDownloadContext(cmd.Context()),
 				utils.WithDownloadDOM(scanResult.UUID),
 				utils.WithDownloadOutput(scanResult.UUID),
 				utils.WithDownloadForce(force),
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -35,7 +36,7 @@ var countCmd = &cobra.Command{
 			return err
 		}
 
-		it, err := client.Search(q, api.IteratorSize(0))
+		it, err := client.Search(q, api.IteratorSize(0), api.IteratorContext(cmd.Context()))
 		if err != nil {
 			return err
 		}
@@ -55,3 +56,39 @@ var countCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(countCmd)
 }
+
+// countSearchResults returns the total number of results of a query without fetching them.
+func countSearchResults(ctx context.Context, client *utils.APIClient, q, datasource, collapse string) (int, error) {
+	it, err := client.Search(q,
+		api.IteratorSize(0),
+		api.IteratorContext(ctx),
+		api.IteratorDatasource(datasource),
+		api.IteratorCollapse(collapse),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, err := range it.Iterate() {
+		if err != nil {
+			return 0, err
+		}
+	}
+	return it.Total, nil
+}
+
+// planExhaustiveSearch finds the date windows of an exhaustive search of a query
+// (see api.IteratorExhaustive) with count-only requests.
+func planExhaustiveSearch(ctx context.Context, client *utils.APIClient, q string, size int, datasource, collapse string) (*api.ExhaustivePlan, error) {
+	it, err := client.Search(q,
+		api.IteratorSize(size),
+		api.IteratorContext(ctx),
+		api.IteratorDatasource(datasource),
+		api.IteratorCollapse(collapse),
+		api.IteratorExhaustive(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return it.PlanExhaustive()
+}
@@ -12,7 +12,9 @@ import (
 )
 
 var rootCmdExample = `  urlscan search <query>
-  echo "<query>" | urlscan search -`
+  echo "<query>" | urlscan search -
+  # export all results to example.jsonl, rerun to continue after an interruption
+  urlscan search "page.domain:example.com" --all --resume example`
 
 var rootCmdLong = `Search by a query.
 
@@ -22,6 +24,20 @@ To discover which fields you can use in a query, use "search fields".
 
 To only count the number of results, use "search count <query>".
 
+With --all, the remaining search quota is checked before fetching the pages. --quota-policy decides what happens
+when the pages exceed the remaining quota of the current minute/hour/day windows: wait for the quota reset,
+truncate the results to the remaining quota, or abort. Use --dry-run to only print the plan.
+
+A query returns at most 10,000 results. With --exhaustive, the query is split into date windows with fewer
+results than the cap (found by count-only requests) and the windows are searched one by one. The quota plan
+covers the results of all the windows and the count-only requests.
+
+With --resume <job-name>, results are appended to the output file as JSON Lines and the cursor is checkpointed
+after every page. Rerunning the same command with the same job name continues where it stopped.
+
+On Ctrl-C (SIGINT), the results collected so far are printed and the search_after of the next page is logged
+(with --resume, the job is left to be resumed). The command exits with code 130.
+
 See https://docs.urlscan.io/pages/search-api-reference for more details.`
 
 var RootCmd = &cobra.Command{
@@ -32,18 +48,22 @@ var RootCmd = &cobra.Command{
 	Annotations: map[string]string{
 		"args": "exact1",
 	},
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		if len(args) != 1 {
 			return cmd.Usage()
 		}
 
 		limit, _ := cmd.Flags().GetInt("limit")
 		all, _ := cmd.Flags().GetBool("all")
+		exhaustive, _ := cmd.Flags().GetBool("exhaustive")
+		// exhaustive search always returns all results
+		all = all || exhaustive
 		size, _ := cmd.Flags().GetInt("size")
 		if limit == 0 {
 			limit = size
 		}
 		searchAfter, _ := cmd.Flags().GetString("search-after")
+		prefetch, _ := cmd.Flags().GetInt("prefetch")
 		datasource, _ := cmd.Flags().GetString("datasource")
 		collapse, _ := cmd.Flags().GetString("collapse")
 
@@ -57,21 +77,143 @@ var RootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		it, err := client.Search(q,
+
+		opts := []api.IteratorOption{
+			api.IteratorContext(cmd.Context()),
 			api.IteratorSize(size),
-			api.IteratorLimit(limit),
 			api.IteratorSearchAfter(searchAfter),
-			api.IteratorAll(all),
+			api.IteratorPrefetch(prefetch),
 			api.IteratorDatasource(datasource),
 			api.IteratorCollapse(collapse),
-		)
+			api.IteratorExhaustive(exhaustive),
+		}
+
+		var job *utils.ResumableJob
+		resumed := 0
+		resume, _ := cmd.Flags().GetString("resume")
+		if resume != "" {
+			output, _ := cmd.Flags().GetString("output")
+			signature := fmt.Sprintf("search q=%q datasource=%q collapse=%q", q, datasource, collapse)
+			job, err = utils.OpenResumableJob(resume, signature, output)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := job.Close()
+				if closeErr != nil && err == nil {
+					err = closeErr
+				}
+			}()
+
+			if job.Done() {
+				client.Logger().Info("The job is already complete, remove the output file to start over", "job", resume, "output", job.Output())
+				return nil
+			}
+			opts = append(opts, api.IteratorCheckpoint(job.Save))
+			cp, ok := job.Checkpoint()
+			if ok {
+				client.Logger().Info("Resuming the job", "job", resume, "count", cp.Count, "total", cp.Total)
+				opts = append(opts, api.IteratorResume(cp))
+				resumed = cp.Count
+			}
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if all || dryRun {
+			quotaPolicy, _ := cmd.Flags().GetString("quota-policy")
+			planner, err := utils.NewQuotaPlanner(client, quotaPolicy, dryRun, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			// fetch the quotas before counting the results, which costs a search request as well
+			err = planner.Fetch(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			target := limit
+			overhead := 0
+			switch {
+			case exhaustive:
+				// the results of all the date windows and the count-only requests to find them
+				plan, err := planExhaustiveSearch(cmd.Context(), client, q, size, datasource, collapse)
+				if err != nil {
+					return err
+				}
+				target, overhead = plan.Total, plan.Requests
+				opts = append(opts, api.IteratorExhaustivePlan(plan))
+			case all:
+				target, err = countSearchResults(cmd.Context(), client, q, datasource, collapse)
+				if err != nil {
+					return err
+				}
+				overhead = 1
+			}
+			target = max(target-resumed, 0)
+			// each page of results costs one search request
+			pageSize := max(size, 1)
+			pages := (target + pageSize - 1) / pageSize
+			n, err := planner.Plan(cmd.Context(), "search", pages, overhead)
+			if err != nil || planner.DryRun() {
+				return err
+			}
+			if n == 0 {
+				// no search request is left in the quota
+				if job != nil {
+					return nil
+				}
+				results := utils.NewSearchResults()
+				results.HasMore = target > 0
+				results.Total = target
+				return printSearchResults(results)
+			}
+			if n < pages {
+				all = false
+				limit = resumed + n*pageSize
+			}
+		}
+
+		opts = append(opts, api.IteratorLimit(limit), api.IteratorAll(all))
+		it, err := client.Search(q, opts...)
 		if err != nil {
 			return err
 		}
 
+		if job != nil {
+			for result, err := range it.Iterate() {
+				if err != nil {
+					if cmd.Context().Err() != nil {
+						client.Logger().Warn("Interrupted, rerun the same command to resume the job", "job", resume, "output", job.Output())
+					}
+					return err
+				}
+				err = job.Write(result.Raw)
+				if err != nil {
+					return err
+				}
+			}
+			client.Logger().Info("Finished the job", "job", resume, "output", job.Output(), "total", it.Total, "hasMore", it.HasMore)
+			return job.Finish(!it.HasMore)
+		}
+
 		results := utils.NewSearchResults()
 		for result, err := range it.Iterate() {
 			if err != nil {
+				if cmd.Context().Err() == nil {
+					return err
+				}
+				// print the results collected so far
+				results.HasMore = true
+				results.Total = it.Total
+				printErr := printSearchResults(results)
+				if printErr != nil {
+					return printErr
+				}
+				if exhaustive {
+					client.Logger().Warn("Interrupted", "count", len(results.Results), "total", it.Total)
+				} else {
+					client.Logger().Warn("Interrupted, rerun with --search-after to continue", "count", len(results.Results), "total", it.Total, "searchAfter", it.Cursor())
+				}
 				return err
 			}
 			results.Results = append(results.Results, result.Raw)
@@ -80,22 +222,33 @@ var RootCmd = &cobra.Command{
 		results.HasMore = it.HasMore
 		results.Total = it.Total
 
-		b, err := json.MarshalIndent(results, "", "  ")
-		if err != nil {
-			return err
-		}
+		return printSearchResults(results)
+	},
+}
 
-		fmt.Print(string(b))
+func printSearchResults(results utils.SearchResults) error {
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
 
-		return nil
-	},
+	fmt.Print(string(b))
+
+	return nil
 }
 
 func init() {
 	flags.AddSizeFlag(RootCmd, 100) // non-pro user's max size is 100
 	flags.AddLimitFlag(RootCmd)
 	flags.AddAllFlag(RootCmd)
+	flags.AddPrefetchFlag(RootCmd)
 	RootCmd.Flags().String("search-after", "", "For retrieving the next batch of results, value of the sort attribute of the last (oldest) result you received (comma-separated)")
 	RootCmd.Flags().StringP("datasource", "D", "scans", "Datasources to search: scans (urlscan.io), hostnames, incidents, notifications, certificates (urlscan Pro)")
 	RootCmd.Flags().StringP("collapse", "c", "", "Field to collapse results on")
+	flags.AddDryRunFlag(RootCmd)
+	flags.AddQuotaPolicyFlag(RootCmd)
+	flags.AddResumeFlags(RootCmd)
+	RootCmd.Flags().Bool("exhaustive", false, fmt.Sprintf("Return all results past the %d results cap by splitting the query into date windows (implies --all)", api.MaxTotal))
+	RootCmd.MarkFlagsMutuallyExclusive("exhaustive", "resume")
+	RootCmd.MarkFlagsMutuallyExclusive("exhaustive", "search-after")
 }
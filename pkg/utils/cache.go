@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/viper"
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+const (
+	cacheDirname = "http"
+	// DefaultCacheMaxSize is the default maximum size of the response cache in MB.
+	DefaultCacheMaxSize = 512
+)
+
+func GetCacheDir() string {
+	return filepath.Join(xdg.CacheHome, namespace, cacheDirname)
+}
+
+// NewDiskCache returns the on-disk response cache configured by --cache-max-size.
+func NewDiskCache() *api.DiskCache {
+	maxSize := viper.GetInt64("cache-max-size")
+	if !viper.IsSet("cache-max-size") {
+		maxSize = DefaultCacheMaxSize
+	}
+	return api.NewDiskCache(GetCacheDir(), maxSize*1024*1024)
+}
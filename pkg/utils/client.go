@@ -1,15 +1,21 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/samber/lo"
 	"github.com/samber/mo"
+	"github.com/spf13/viper"
 	"github.com/urlscan/urlscan-cli/api"
 	"github.com/urlscan/urlscan-cli/pkg/version"
 )
@@ -33,19 +39,136 @@ func GetKey() (string, error) {
 	return key, nil
 }
 
-func NewAPIClient() (*APIClient, error) {
+// GetKeys returns the API keys. Multiple keys (e.g. keys with separate quotas) can be set
+// to the URLSCAN_API_KEYS environment variable as a comma-separated list, otherwise
+// the key from GetKey is used.
+func GetKeys() ([]string, error) {
+	var keys []string
+	for key := range strings.SplitSeq(os.Getenv("URLSCAN_API_KEYS"), ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		return keys, nil
+	}
+
 	key, err := GetKey()
 	if err != nil {
 		return nil, err
 	}
+	if key == "" {
+		return nil, nil
+	}
+	return []string{key}, nil
+}
+
+// debugHTTPWriter returns the writer for --debug-http dumps, the file is opened only once per process.
+var debugHTTPWriter = sync.OnceValues(func() (io.Writer, error) {
+	path := viper.GetString("debug-http-file")
+	if path == "" {
+		return os.Stderr, nil
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+})
 
-	c := api.NewClient(key)
+// newTransportOptions returns the proxy and TLS options set by the flags.
+func newTransportOptions() []api.TransportOption {
+	var opts []api.TransportOption
+	if proxy := viper.GetString("proxy"); proxy != "" {
+		opts = append(opts, api.WithTransportProxy(proxy))
+	}
+	if caCerts := viper.GetStringSlice("ca-cert"); len(caCerts) > 0 {
+		opts = append(opts, api.WithTransportRootCAs(caCerts...))
+	}
+	clientCert, clientKey := viper.GetString("client-cert"), viper.GetString("client-key")
+	if clientCert != "" || clientKey != "" {
+		opts = append(opts, api.WithTransportClientCert(clientCert, clientKey))
+	}
+	if version := viper.GetString("tls-min-version"); version != "" {
+		opts = append(opts, api.WithTransportMinTLSVersion(version))
+	}
+	return opts
+}
+
+// baseURL returns the base URL of the API set by --base-url (or the legacy --host).
+func baseURL() (*url.URL, error) {
+	s := viper.GetString("base-url")
+	if host := viper.GetString("host"); host != "" && (s == "" || s == api.DefaultBaseURL) {
+		s = host
+	}
+	if s == "" {
+		s = api.DefaultBaseURL
+	}
+	return api.ParseBaseURL(s)
+}
+
+func NewAPIClient() (*APIClient, error) {
+	record := viper.GetString("record")
+	replay := viper.GetString("replay")
+	if record != "" && replay != "" {
+		return nil, fmt.Errorf("--record and --replay cannot be used together")
+	}
+
+	keys, err := GetKeys()
+	// the API key is not needed for replaying
+	if err != nil && replay == "" {
+		return nil, err
+	}
+
+	u, err := baseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	c := api.NewClient("")
 	c.Agent = fmt.Sprintf("urlscan-cli %s", version.Version)
+	c.SetBaseURL(u)
+	switch {
+	case len(keys) > 1:
+		// spread requests over the keys and rotate them on quota exhaustion
+		c.SetKeyPool(api.NewKeyPool(keys...))
+	case len(keys) == 1:
+		c.SetAPIKey(keys[0])
+	}
+
+	switch {
+	case record != "":
+		c.SetCassette(api.NewCassetteRecorder(nil, record))
+	case replay != "":
+		c.SetCassette(api.NewCassetteReplayer(replay))
+	}
+
+	// the cache is bypassed while recording/replaying to keep the cassette complete
+	if !viper.GetBool("no-cache") && record == "" && replay == "" {
+		cache := api.NewCache(NewDiskCache())
+		cache.Rules = api.DefaultCacheRules(viper.GetDuration("cache-ttl"))
+		c.SetCache(cache)
+	}
+
+	transportOpts := newTransportOptions()
+	if len(transportOpts) > 0 {
+		transport, err := api.NewHTTPTransport(transportOpts...)
+		if err != nil {
+			return nil, err
+		}
+		c.SetHTTPTransport(transport)
+	}
+
+	if viper.GetBool("debug-http") {
+		w, err := debugHTTPWriter()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the HTTP debug file: %w", err)
+		}
+		c.SetDebugHTTP(w, viper.GetInt("debug-http-max-body"))
+	}
 
 	return &APIClient{c}, nil
 }
 
 type DownloadOptions struct {
+	ctx             context.Context
 	client          *APIClient
 	path            string
 	output          string
@@ -62,6 +185,12 @@ func WithDownloadClient(client *APIClient) DownloadOption {
 	}
 }
 
+func WithDownloadContext(ctx context.Context) DownloadOption {
+	return func(opts *DownloadOptions) {
+		opts.ctx = ctx
+	}
+}
+
 func WithDownloadURL(path string) DownloadOption {
 	return func(opts *DownloadOptions) {
 		opts.path = path
@@ -128,7 +257,12 @@ func Download(opts *DownloadOptions) error {
 		}
 	}
 
-	_, err := opts.client.Download(opts.path, output)
+	ctx := opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	_, err := opts.client.DownloadContext(ctx, opts.path, output)
 	if err != nil {
 		return err
 	}
@@ -162,11 +296,13 @@ type BatchJSONResultPair struct {
 	Result json.RawMessage `json:"result"`
 }
 
+func NewBatchJSONResultPair(key string, result mo.Result[*api.Response]) *BatchJSONResultPair {
+	return &BatchJSONResultPair{
+		Key:    key,
+		Result: *api.BatchResultToRaw(result),
+	}
+}
+
 func NewBatchJSONResultPairs(keys []string, results []mo.Result[*api.Response]) []*BatchJSONResultPair {
-	return lo.ZipBy2(keys, results, func(url string, result mo.Result[*api.Response]) *BatchJSONResultPair {
-		return &BatchJSONResultPair{
-			Key:    url,
-			Result: *api.BatchResultToRaw(result),
-		}
-	})
+	return lo.ZipBy2(keys, results, NewBatchJSONResultPair)
 }
@@ -1,18 +1,54 @@
 package utils
 
 import (
+	"encoding/json"
 	"path/filepath"
 
 	"github.com/adrg/xdg"
 	"go.etcd.io/bbolt"
+
+	"github.com/urlscan/urlscan-cli/api"
 )
 
 const (
 	namespace          = "urlscan"
 	databaseFilename   = "state.db"
 	dataDumpBucketName = "datadump"
+	jobBucketName      = "jobs"
+)
+
+const (
+	DataDumpStatePartial  = "partial"
+	DataDumpStateComplete = "complete"
 )
 
+type DataDumpEntry struct {
+	LocalPath string `json:"localPath"`
+	State     string `json:"state"`
+}
+
+// decodeDataDumpEntry decodes a datadump bucket value. A plain local path
+// (written by older versions) is treated as a complete download.
+func decodeDataDumpEntry(v []byte) DataDumpEntry {
+	var entry DataDumpEntry
+	err := json.Unmarshal(v, &entry)
+	if err != nil || entry.LocalPath == "" {
+		return DataDumpEntry{LocalPath: string(v), State: DataDumpStateComplete}
+	}
+	return entry
+}
+
+// JobState is the state of a resumable job (e.g. "search --all --resume <job>").
+type JobState struct {
+	// Signature identifies the command and the arguments the job was started with.
+	Signature string `json:"signature"`
+	Output    string `json:"output"`
+	// Offset is the size of the output file at the checkpoint.
+	Offset     int64          `json:"offset"`
+	Checkpoint api.Checkpoint `json:"checkpoint"`
+	Done       bool           `json:"done"`
+}
+
 type Database struct {
 	*bbolt.DB
 }
@@ -33,8 +69,13 @@ func NewDatabase() (d *Database, err error) {
 	}
 
 	err = db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(dataDumpBucketName))
-		return err
+		for _, name := range []string{dataDumpBucketName, jobBucketName} {
+			_, err := tx.CreateBucketIfNotExists([]byte(name))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		defer func() {
@@ -53,27 +94,47 @@ func (d *Database) Close() error {
 	return d.DB.Close()
 }
 
-func (d *Database) GetDataDump(path string) (localPath string, exists bool, err error) {
+func (d *Database) GetDataDumpEntry(path string) (entry DataDumpEntry, exists bool, err error) {
 	err = d.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(dataDumpBucketName))
 		v := b.Get([]byte(path))
 		if v != nil {
-			localPath = string(v)
+			entry = decodeDataDumpEntry(v)
 			exists = true
 		}
 		return nil
 	})
 
-	return localPath, exists, err
+	return entry, exists, err
 }
 
-func (d *Database) SetDataDump(path, localPath string) error {
+func (d *Database) GetDataDump(path string) (localPath string, exists bool, err error) {
+	entry, exists, err := d.GetDataDumpEntry(path)
+	return entry.LocalPath, exists, err
+}
+
+func (d *Database) setDataDumpEntry(path string, entry DataDumpEntry) error {
+	v, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
 	return d.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(dataDumpBucketName))
-		return b.Put([]byte(path), []byte(localPath))
+		return b.Put([]byte(path), v)
 	})
 }
 
+// SetDataDump marks the datadump path as completely downloaded to localPath.
+func (d *Database) SetDataDump(path, localPath string) error {
+	return d.setDataDumpEntry(path, DataDumpEntry{LocalPath: localPath, State: DataDumpStateComplete})
+}
+
+// SetDataDumpPartial marks the datadump path as being downloaded to localPath.
+func (d *Database) SetDataDumpPartial(path, localPath string) error {
+	return d.setDataDumpEntry(path, DataDumpEntry{LocalPath: localPath, State: DataDumpStatePartial})
+}
+
 func (d *Database) DeleteDataDump(path string) error {
 	return d.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(dataDumpBucketName))
@@ -82,15 +143,15 @@ func (d *Database) DeleteDataDump(path string) error {
 }
 
 func (d *Database) HasDataDumpBeenDownloaded(path string) (bool, error) {
-	localPath, exists, err := d.GetDataDump(path)
+	entry, exists, err := d.GetDataDumpEntry(path)
 	if err != nil {
 		return false, err
 	}
-	if !exists {
+	if !exists || entry.State != DataDumpStateComplete {
 		return false, nil
 	}
 
-	if !fileExists(localPath) {
+	if !fileExists(entry.LocalPath) {
 		// if file is deleted, remove it from the database
 		err := d.DeleteDataDump(path)
 		if err != nil {
@@ -101,3 +162,36 @@ func (d *Database) HasDataDumpBeenDownloaded(path string) (bool, error) {
 
 	return true, nil
 }
+
+func (d *Database) GetJob(name string) (state JobState, exists bool, err error) {
+	err = d.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(jobBucketName))
+		v := b.Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+		exists = true
+		return json.Unmarshal(v, &state)
+	})
+
+	return state, exists, err
+}
+
+func (d *Database) SetJob(name string, state JobState) error {
+	v, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return d.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(jobBucketName))
+		return b.Put([]byte(name), v)
+	})
+}
+
+func (d *Database) DeleteJob(name string) error {
+	return d.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(jobBucketName))
+		return b.Delete([]byte(name))
+	})
+}
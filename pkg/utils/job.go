@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+// ResumableJob appends the results of an iteration to the output file as JSON Lines and
+// persists the iterator checkpoint in the state database after every page. The results of
+// a page are written to the file together with its checkpoint, so a rerun after a crash
+// neither skips nor duplicates results.
+type ResumableJob struct {
+	name    string
+	db      *Database
+	state   JobState
+	resumed bool
+	file    *os.File
+	pending bytes.Buffer
+}
+
+// OpenResumableJob opens the job or starts a new one. signature identifies the command and
+// the arguments and a job can't be resumed with another signature. If output is empty,
+// the output file of the existing job (or "<name>.jsonl") is used. A job whose output file
+// has been removed starts over.
+func OpenResumableJob(name, signature, output string) (*ResumableJob, error) {
+	db, err := NewDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := newResumableJob(db, name, signature, output)
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return job, nil
+}
+
+func newResumableJob(db *Database, name, signature, output string) (*ResumableJob, error) {
+	state, exists, err := db.GetJob(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if state.Signature != signature {
+			return nil, fmt.Errorf("job %q was started with different arguments (%s)", name, state.Signature)
+		}
+		if output != "" && output != state.Output {
+			return nil, fmt.Errorf("job %q writes to %s, not %s", name, state.Output, output)
+		}
+		if !fileExists(state.Output) {
+			exists = false
+			output = state.Output
+		}
+	}
+	if !exists {
+		if output == "" {
+			output = name + ".jsonl"
+		}
+		// never truncate a file which is not written by the job
+		if fileExists(output) {
+			return nil, fmt.Errorf("%s already exists, remove it or choose another output file", output)
+		}
+		state = JobState{Signature: signature, Output: output, Offset: 0, Checkpoint: api.Checkpoint{}, Done: false}
+	}
+
+	file, err := os.OpenFile(state.Output, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// drop the results written after the last checkpoint
+	err = file.Truncate(state.Offset)
+	if err == nil {
+		_, err = file.Seek(state.Offset, io.SeekStart)
+	}
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+
+	return &ResumableJob{name: name, db: db, state: state, resumed: exists, file: file, pending: bytes.Buffer{}}, nil
+}
+
+func (j *ResumableJob) Output() string {
+	return j.state.Output
+}
+
+// Done reports whether the job has already been completed.
+func (j *ResumableJob) Done() bool {
+	return j.state.Done
+}
+
+// Checkpoint returns the checkpoint to resume from. It returns false if the job is new.
+func (j *ResumableJob) Checkpoint() (api.Checkpoint, bool) {
+	return j.state.Checkpoint, j.resumed
+}
+
+// Write buffers a result until the next checkpoint.
+func (j *ResumableJob) Write(result json.RawMessage) error {
+	err := json.Compact(&j.pending, result)
+	if err != nil {
+		return err
+	}
+	return j.pending.WriteByte('\n')
+}
+
+func (j *ResumableJob) flush() error {
+	_, err := j.pending.WriteTo(j.file)
+	if err != nil {
+		return err
+	}
+	err = j.file.Sync()
+	if err != nil {
+		return err
+	}
+	j.state.Offset, err = j.file.Seek(0, io.SeekCurrent)
+	return err
+}
+
+// Save writes the buffered results and persists the checkpoint. It's meant to be
+// passed to api.IteratorCheckpoint or api.HostnameIteratorCheckpoint.
+func (j *ResumableJob) Save(cp api.Checkpoint) error {
+	err := j.flush()
+	if err != nil {
+		return err
+	}
+	j.state.Checkpoint = cp
+	return j.db.SetJob(j.name, j.state)
+}
+
+// Finish writes the remaining results. The job is marked as done if done is true. Otherwise
+// (e.g. the iteration stopped at a limit in the middle of a page), the results after the last
+// checkpoint are written but a rerun drops and fetches them again.
+func (j *ResumableJob) Finish(done bool) error {
+	err := j.flush()
+	if err != nil || !done {
+		return err
+	}
+	j.state.Done = true
+	return j.db.SetJob(j.name, j.state)
+}
+
+func (j *ResumableJob) Close() error {
+	fileErr := j.file.Close()
+	dbErr := j.db.Close()
+	if fileErr != nil {
+		return fileErr
+	}
+	return dbErr
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+func newTestJobDatabase(t *testing.T) *Database {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, nil)
+	assert.NoError(t, err)
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(jobBucketName))
+		return err
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) // nolint:errcheck
+	return &Database{DB: db}
+}
+
+func TestResumableJob(t *testing.T) {
+	db := newTestJobDatabase(t)
+	output := filepath.Join(t.TempDir(), "out.jsonl")
+
+	job, err := newResumableJob(db, "test", "search q=foo", output)
+	assert.NoError(t, err)
+	_, ok := job.Checkpoint()
+	assert.False(t, ok)
+
+	// the first page is checkpointed
+	assert.NoError(t, job.Write(json.RawMessage(`{"id": 1}`)))
+	assert.NoError(t, job.Save(api.Checkpoint{Cursor: "1,a", Count: 1, Total: 3, HasMore: true}))
+	// the second page is interrupted before the checkpoint
+	assert.NoError(t, job.Write(json.RawMessage(`{"id": 2}`)))
+	assert.NoError(t, job.flush())
+	assert.NoError(t, job.file.Close())
+
+	job, err = newResumableJob(db, "test", "search q=foo", "")
+	assert.NoError(t, err)
+	cp, ok := job.Checkpoint()
+	assert.True(t, ok)
+	assert.Equal(t, api.Checkpoint{Cursor: "1,a", Count: 1, Total: 3, HasMore: true}, cp)
+
+	assert.NoError(t, job.Write(json.RawMessage(`{"id": 2}`)))
+	assert.NoError(t, job.Write(json.RawMessage(`{"id": 3}`)))
+	assert.NoError(t, job.Finish(true))
+	assert.NoError(t, job.file.Close())
+
+	b, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n", string(b))
+
+	job, err = newResumableJob(db, "test", "search q=foo", "")
+	assert.NoError(t, err)
+	assert.True(t, job.Done())
+	assert.NoError(t, job.file.Close())
+}
+
+func TestResumableJobMismatch(t *testing.T) {
+	db := newTestJobDatabase(t)
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.jsonl")
+
+	job, err := newResumableJob(db, "test", "search q=foo", output)
+	assert.NoError(t, err)
+	assert.NoError(t, job.Save(api.Checkpoint{Cursor: "1,a", Count: 1, Total: 3, HasMore: true}))
+	assert.NoError(t, job.file.Close())
+
+	_, err = newResumableJob(db, "test", "search q=bar", "")
+	assert.Error(t, err)
+
+	_, err = newResumableJob(db, "test", "search q=foo", filepath.Join(dir, "other.jsonl"))
+	assert.Error(t, err)
+
+	// a file not written by a job is never overwritten
+	_, err = newResumableJob(db, "other", "search q=foo", output)
+	assert.Error(t, err)
+
+	// the job starts over when the output file is removed
+	assert.NoError(t, os.Remove(output))
+	job, err = newResumableJob(db, "test", "search q=foo", "")
+	assert.NoError(t, err)
+	_, ok := job.Checkpoint()
+	assert.False(t, ok)
+	assert.Equal(t, output, job.Output())
+	assert.NoError(t, job.file.Close())
+}
+
+func TestResumableJobUnfinished(t *testing.T) {
+	db := newTestJobDatabase(t)
+	output := filepath.Join(t.TempDir(), "out.jsonl")
+
+	job, err := newResumableJob(db, "test", "search q=foo", output)
+	assert.NoError(t, err)
+	assert.NoError(t, job.Write(json.RawMessage(`{"id":1}`)))
+	assert.NoError(t, job.Save(api.Checkpoint{Cursor: "1,a", Count: 1, Total: 3, HasMore: true}))
+	// stopped at a limit in the middle of the next page
+	assert.NoError(t, job.Write(json.RawMessage(`{"id":2}`)))
+	assert.NoError(t, job.Finish(false))
+	assert.NoError(t, job.file.Close())
+
+	b, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", string(b))
+
+	// a rerun continues from the last checkpoint
+	job, err = newResumableJob(db, "test", "search q=foo", "")
+	assert.NoError(t, err)
+	assert.False(t, job.Done())
+	cp, ok := job.Checkpoint()
+	assert.True(t, ok)
+	assert.Equal(t, 1, cp.Count)
+	assert.NoError(t, job.file.Close())
+
+	b, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":1}\n", string(b))
+}
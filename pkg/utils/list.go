@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+// PrintList prints the response of a list iterator as it is, with the items of the key
+// replaced by the ones returned by the iterator (e.g. the first --limit items).
+func PrintList(key string, it *api.PageIterator[json.RawMessage]) error {
+	items := make([]json.RawMessage, 0)
+	for item, err := range it.Iterate() {
+		if err != nil {
+			return err
+		}
+		items = append(items, *item)
+	}
+
+	value, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	envelope := it.Raw
+	if envelope == nil {
+		envelope = []byte("{}")
+	}
+	body, err := replaceField(envelope, key, value)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	err = json.Indent(&b, body, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(b.String())
+
+	return nil
+}
+
+// replaceField returns the JSON object with the value of the field replaced (or added if
+// it's missing), keeping the order of the other fields.
+func replaceField(object []byte, field string, value json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(object))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	replaced := false
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid JSON object key: %v", tok)
+		}
+		var raw json.RawMessage
+		err = dec.Decode(&raw)
+		if err != nil {
+			return nil, err
+		}
+		if name == field {
+			raw = value
+			replaced = true
+		}
+		writeField(&b, name, raw)
+	}
+	if !replaced {
+		writeField(&b, field, value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func writeField(b *bytes.Buffer, name string, value json.RawMessage) {
+	if b.Len() > 1 {
+		b.WriteByte(',')
+	}
+	key, _ := json.Marshal(name)
+	b.Write(key)
+	b.WriteByte(':')
+	b.Write(value)
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceField(t *testing.T) {
+	body, err := replaceField([]byte(`{"total": 3, "channels": [1, 2, 3], "meta": {"a": 1}}`), "channels", []byte(`[1,2]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"total":3,"channels":[1,2],"meta":{"a": 1}}`, string(body))
+
+	body, err = replaceField([]byte(`{}`), "channels", []byte(`[]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"channels":[]}`, string(body))
+
+	_, err = replaceField([]byte(`[]`), "channels", []byte(`[]`))
+	assert.Error(t, err)
+}
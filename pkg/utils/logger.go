@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+var LogFormats = []string{"text", "json"}
+
+func parseLogLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(level))
+	if err != nil {
+		return l, fmt.Errorf("invalid log level: %q, must be one of debug, info, warn, error", level)
+	}
+	return l, nil
+}
+
+// NewLogger creates a logger writing to w with the given level (debug, info, warn, error)
+// and format (text, json).
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	l, err := parseLogLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: l, AddSource: false, ReplaceAttr: nil}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format: %q, must be one of %s", format, strings.Join(LogFormats, ", "))
+	}
+}
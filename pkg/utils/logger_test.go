@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Run("json format", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&buf, "info", "json")
+		assert.NoError(t, err)
+
+		logger.Info("Sleeping for 1 seconds", "X-Rate-Limit-Reset-After", "1")
+		logger.Debug("not logged")
+
+		var got map[string]any
+		err = json.Unmarshal(buf.Bytes(), &got)
+		assert.NoError(t, err)
+		assert.Equal(t, "Sleeping for 1 seconds", got["msg"])
+		assert.Equal(t, "1", got["X-Rate-Limit-Reset-After"])
+	})
+
+	t.Run("text format with debug level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&buf, "DEBUG", "text")
+		assert.NoError(t, err)
+
+		logger.Debug("logged")
+		assert.Contains(t, buf.String(), "level=DEBUG msg=logged")
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		_, err := NewLogger(&bytes.Buffer{}, "verbose", "text")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := NewLogger(&bytes.Buffer{}, "info", "xml")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// BatchProgress draws a live progress line of a batch (submitted / waiting / done / failed)
+// on stderr. Nothing is drawn if stderr is not a terminal. The methods are safe for concurrent use.
+type BatchProgress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	total int
+	// submitted is the tasks whose scan was submitted successfully (once per task across retries)
+	submitted map[int]struct{}
+	waiting   map[int]struct{}
+	done      int
+	failed    int
+	drawn     bool
+}
+
+func NewBatchProgress(total int) *BatchProgress {
+	var w io.Writer
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		w = os.Stderr
+	}
+	return newBatchProgress(w, total)
+}
+
+func newBatchProgress(w io.Writer, total int) *BatchProgress {
+	return &BatchProgress{
+		mu:        sync.Mutex{},
+		w:         w,
+		total:     total,
+		submitted: make(map[int]struct{}),
+		waiting:   make(map[int]struct{}),
+		done:      0,
+		failed:    0,
+		drawn:     false,
+	}
+}
+
+// Submitted marks the scan of the task as submitted successfully.
+func (p *BatchProgress) Submitted(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.submitted[index] = struct{}{}
+	p.draw()
+}
+
+// Waiting marks the task as waiting for its result.
+func (p *BatchProgress) Waiting(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.waiting[index] = struct{}{}
+	p.draw()
+}
+
+// OnDone is meant to be passed to api.WithBatchOnDone.
+func (p *BatchProgress) OnDone(index int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.waiting, index)
+	if err != nil {
+		p.failed++
+	} else {
+		p.done++
+	}
+	p.draw()
+}
+
+// Finish ends the progress line. Nothing is drawn after it.
+func (p *BatchProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w != nil && p.drawn {
+		fmt.Fprintln(p.w)
+	}
+	p.w = nil
+}
+
+func (p *BatchProgress) String() string {
+	return fmt.Sprintf("submitted: %d/%d, waiting: %d, done: %d, failed: %d", len(p.submitted), p.total, len(p.waiting), p.done, p.failed)
+}
+
+func (p *BatchProgress) draw() {
+	if p.w == nil {
+		return
+	}
+	// rewrite the line in place
+	fmt.Fprintf(p.w, "\r\033[K%s", p.String())
+	p.drawn = true
+}
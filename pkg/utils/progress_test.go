@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := newBatchProgress(&buf, 3)
+
+	p.Submitted(0)
+	p.Submitted(1)
+	// a retried task is submitted again
+	p.Submitted(1)
+	p.Waiting(0)
+	assert.Equal(t, "submitted: 2/3, waiting: 1, done: 0, failed: 0", p.String())
+
+	p.OnDone(0, nil)
+	p.OnDone(1, errors.New("failed"))
+	assert.Equal(t, "submitted: 2/3, waiting: 0, done: 1, failed: 1", p.String())
+
+	p.Finish()
+	assert.True(t, strings.HasSuffix(buf.String(), "\r\033[Ksubmitted: 2/3, waiting: 0, done: 1, failed: 1\n"))
+}
+
+func TestBatchProgressNotTerminal(t *testing.T) {
+	p := newBatchProgress(nil, 1)
+	p.Submitted(0)
+	p.OnDone(0, nil)
+	p.Finish()
+	assert.Equal(t, "submitted: 1/1, waiting: 0, done: 1, failed: 0", p.String())
+}
+
+func TestBatchProgressFailedSubmission(t *testing.T) {
+	var buf bytes.Buffer
+	p := newBatchProgress(&buf, 1)
+	p.OnDone(0, errors.New("failed"))
+	p.Finish()
+	assert.Equal(t, "\r\033[Ksubmitted: 0/1, waiting: 0, done: 0, failed: 1\n", buf.String())
+}
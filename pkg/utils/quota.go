@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+// QuotaPlanner plans a bulk operation against the remaining quotas before it starts.
+type QuotaPlanner struct {
+	client *APIClient
+	policy api.QuotaPolicy
+	dryRun bool
+	// out is the writer of the plan on a dry run
+	out    io.Writer
+	quotas *api.Quotas
+	// fetched is set once the quotas are fetched (or failed to be)
+	fetched bool
+}
+
+func NewQuotaPlanner(client *APIClient, policy string, dryRun bool, out io.Writer) (*QuotaPlanner, error) {
+	p, err := api.ParseQuotaPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaPlanner{client: client, policy: p, dryRun: dryRun, out: out, quotas: nil, fetched: false}, nil
+}
+
+func (p *QuotaPlanner) DryRun() bool {
+	return p.dryRun
+}
+
+// Fetch gets the remaining quotas. Call it before sending the requests made to plan
+// the job (e.g. counting the search results), so they are planned as well (see Plan).
+// If the quotas can't be fetched, the plan is skipped (except for a dry run).
+func (p *QuotaPlanner) Fetch(ctx context.Context) error {
+	if p.fetched {
+		return nil
+	}
+
+	quotas, err := p.client.GetQuotasContext(ctx)
+	if err != nil {
+		if p.dryRun {
+			return err
+		}
+		p.client.Logger().Warn("Failed to get quotas, skipping the quota check", "error", err.Error())
+	}
+	p.quotas = quotas
+	p.fetched = true
+	return nil
+}
+
+// Plan returns the number of requests of the action to send out of n under the policy.
+// overhead is the number of requests of the action sent after Fetch to plan the job, which
+// are counted against the quota as well. On a dry run, it prints the plan and returns zero.
+// Under the wait policy, the client holds the requests once the quota is used up.
+func (p *QuotaPlanner) Plan(ctx context.Context, action string, n, overhead int) (int, error) {
+	err := p.Fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if p.quotas == nil {
+		return n, nil
+	}
+
+	plan := p.quotas.Plan(action, overhead+n)
+	if p.dryRun {
+		b, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		_, err = fmt.Fprint(p.out, string(b))
+		return 0, err
+	}
+
+	allowed, err := plan.Apply(p.policy)
+	if err != nil {
+		return 0, err
+	}
+	if plan.Exceeded() {
+		p.client.Logger().Info("The job exceeds the remaining quota",
+			"action", action, "requested", plan.Requested, "allowed", plan.Allowed, "window", plan.LimitingWindow,
+			"resetAt", plan.ResetAt.Format(time.RFC3339), "policy", string(p.policy))
+		if p.policy == api.QuotaPolicyWait {
+			p.client.WaitForQuota(action, max(plan.Allowed-overhead, 0), plan.ResetAt)
+		}
+	}
+	return max(allowed-overhead, 0), nil
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/urlscan/urlscan-cli/api"
+)
+
+const searchQuotas = `{"scope":"team","limits":{"search":{"minute":{"limit":60,"used":50,"remaining":10,"percent":83.33}}}}`
+
+func TestQuotaPlanner(t *testing.T) {
+	defer gock.Off()
+
+	t.Run("plans the overhead as well", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver").Get("/api/v1/quotas").Times(1).Reply(200).BodyString(searchQuotas)
+
+		planner, err := NewQuotaPlanner(newTestClient(), "truncate", false, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, planner.Fetch(t.Context()))
+
+		// 1 of 10 requests is spent by the overhead
+		n, err := planner.Plan(t.Context(), "search", 20, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 9, n)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("aborts on the overhead", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver").Get("/api/v1/quotas").Times(1).Reply(200).BodyString(searchQuotas)
+
+		planner, err := NewQuotaPlanner(newTestClient(), "abort", false, nil)
+		assert.NoError(t, err)
+		_, err = planner.Plan(t.Context(), "search", 10, 1)
+		assert.ErrorIs(t, err, api.ErrQuotaExceeded)
+	})
+
+	t.Run("prints the plan to the writer on a dry run", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver").Get("/api/v1/quotas").Times(1).Reply(200).BodyString(searchQuotas)
+
+		var out bytes.Buffer
+		planner, err := NewQuotaPlanner(newTestClient(), "wait", true, &out)
+		assert.NoError(t, err)
+		n, err := planner.Plan(t.Context(), "search", 5, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		var plan api.QuotaPlan
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &plan))
+		assert.Equal(t, 6, plan.Requested)
+		assert.Equal(t, 6, plan.Allowed)
+	})
+
+	t.Run("skips the plan if the quotas can't be fetched", func(t *testing.T) {
+		defer gock.Clean()
+
+		gock.New("http://testserver").Get("/api/v1/quotas").Times(1).Reply(403).JSON(map[string]any{"status": 403, "message": "Forbidden"})
+
+		planner, err := NewQuotaPlanner(newTestClient(), "abort", false, nil)
+		assert.NoError(t, err)
+		n, err := planner.Plan(t.Context(), "search", 20, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 20, n)
+	})
+}